@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
 
 	"github.com/josephburnett/time-flies/pkg/cmd"
@@ -15,5 +17,15 @@ func main() {
 	root.AddCommand(cmd.CmdTotals)
 	root.AddCommand(cmd.CmdEdit)
 	root.AddCommand(cmd.CmdTodo)
-	root.Execute()
+	root.AddCommand(cmd.CmdBudget)
+	root.AddCommand(cmd.CmdAdd)
+	root.AddCommand(cmd.CmdWeek)
+	root.AddCommand(cmd.CmdLint)
+	root.AddCommand(cmd.CmdReport)
+	root.AddCommand(cmd.CmdDone)
+	root.AddCommand(cmd.CmdRules)
+	root.AddCommand(cmd.CmdSuggest)
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
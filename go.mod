@@ -4,7 +4,7 @@ go 1.14
 
 require (
 	github.com/keegancsmith/rpc v1.3.0 // indirect
-	github.com/niklasfasching/go-org v1.5.0 // indirect
+	github.com/niklasfasching/go-org v1.5.0
 	github.com/spf13/cobra v1.0.0
 	github.com/spf13/pflag v1.0.3
 	github.com/stamblerre/gocode v1.0.0 // indirect
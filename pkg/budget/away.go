@@ -0,0 +1,117 @@
+package budget
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/josephburnett/time-flies/pkg/calendar"
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+const (
+	awayDateFormat = "2006-01-02"
+	timeAwayValue  = "time-away"
+)
+
+// DateRange is an inclusive range of dates like 2006-01-02.
+type DateRange struct {
+	From string
+	To   string
+}
+
+func (c *BudgetConfig) timeAwayEntries() bool {
+	if c == nil || c.TimeAwayEntries == nil {
+		return false
+	}
+	return *c.TimeAwayEntries
+}
+
+// daysAway returns the set of holidays and days of PTO, keyed by date.
+// The holiday calendar is read once.
+func (c *BudgetConfig) daysAway() (map[string]bool, error) {
+	if c == nil {
+		return map[string]bool{}, nil
+	}
+	if c.away != nil {
+		return c.away, nil
+	}
+	away := map[string]bool{}
+	for _, h := range c.Holidays {
+		t, err := time.Parse(awayDateFormat, h)
+		if err != nil {
+			return nil, fmt.Errorf("malformed holiday %q: want 2006-01-02", h)
+		}
+		away[t.Format(awayDateFormat)] = true
+	}
+	if c.HolidayCalendar != nil {
+		days, err := calendar.ReadICS(*c.HolidayCalendar)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range days {
+			away[d.Format(awayDateFormat)] = true
+		}
+	}
+	for _, r := range c.TimeAway {
+		from, err := time.Parse(awayDateFormat, r.From)
+		if err != nil {
+			return nil, fmt.Errorf("malformed time away %q: want 2006-01-02", r.From)
+		}
+		to := from
+		if r.To != "" {
+			to, err = time.Parse(awayDateFormat, r.To)
+			if err != nil {
+				return nil, fmt.Errorf("malformed time away %q: want 2006-01-02", r.To)
+			}
+		}
+		for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+			away[d.Format(awayDateFormat)] = true
+		}
+	}
+	c.away = away
+	return away, nil
+}
+
+// weekTimeAway returns the working time of a week spent on holidays and
+// PTO. Weeks with their own Days or Hours header are left as written.
+func (c *BudgetConfig) weekTimeAway(week *types.Week) (time.Duration, error) {
+	days, err := c.weekDaysAway(week)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(len(days)) * time.Duration(c.hoursPerDay()) * time.Hour, nil
+}
+
+// weekDaysAway returns the working days of a week which are holidays or
+// PTO. Weeks with their own Days or Hours header have none.
+func (c *BudgetConfig) weekDaysAway(week *types.Week) ([]time.Time, error) {
+	if _, ok := week.Header[daysHeader]; ok {
+		return nil, nil
+	}
+	if _, ok := week.Header[hoursHeader]; ok {
+		return nil, nil
+	}
+	away, err := c.daysAway()
+	if err != nil {
+		return nil, err
+	}
+	days := []time.Time{}
+	for i := 0; i < c.daysPerWeek(); i++ {
+		day := week.Date.AddDate(0, 0, i)
+		if away[day.Format(awayDateFormat)] {
+			days = append(days, day)
+		}
+	}
+	return days, nil
+}
+
+// timeAwayEntry is a synthetic entry accounting for time away.
+func (c *BudgetConfig) timeAwayEntry(away time.Duration) *types.Entry {
+	return &types.Entry{
+		Line: "time away",
+		Labels: map[string]string{
+			c.labelGrouping()[0]: timeAwayValue,
+			"t":                  away.String(),
+		},
+	}
+}
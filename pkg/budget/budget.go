@@ -2,6 +2,10 @@ package budget
 
 import (
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/josephburnett/time-flies/pkg/types"
@@ -20,25 +24,37 @@ type Total struct {
 type SubTotals []*SubTotal
 
 type SubTotal struct {
-	Label     string
-	Value     string
+	Label string
+	// Value is one segment of a path-valued label like
+	// "primary/thing-one", or the whole value of any other label.
+	Value string
+	// Path is the label value shared by every entry of the SubTotal, e.g.
+	// "primary/thing-one" for the thing-one segment under primary. It is
+	// the same as Value for labels which aren't paths.
+	Path      string
 	Relative  float64
 	Absolute  time.Duration
 	Count     int
+	Variance  float64
 	SubTotals SubTotals
 }
 
 type Period string
 
 const (
+	Daily     Period = "Daily"
 	Weekly    Period = "Weekly"
-	Monthly          = "Monthly"
-	Quarterly        = "Quarterly"
+	Monthly   Period = "Monthly"
+	Quarterly Period = "Quarterly"
+	Yearly    Period = "Yearly"
 
 	defaultAggregationPeriod = Weekly
 	defaultDaysPerWeek       = 5
 	defaultHoursPerDay       = 8
 	defaultMinutesPerEntry   = 30
+
+	daysHeader  = "Days"
+	hoursHeader = "Hours"
 )
 
 var (
@@ -54,6 +70,13 @@ type BudgetConfig struct {
 	HoursPerDay       *int
 	MinutesPerEntry   *int
 	LabelGrouping     []string
+	Targets           []*Target
+	Holidays          []string
+	HolidayCalendar   *string
+	TimeAway          []*DateRange
+	TimeAwayEntries   *bool
+
+	away map[string]bool
 }
 
 func (c *BudgetConfig) aggregationPeriod() Period {
@@ -91,7 +114,25 @@ func (c *BudgetConfig) labelGrouping() []string {
 	return c.LabelGrouping
 }
 
+// GetLabelGrouping returns the labels by which entries are grouped.
+func (c *BudgetConfig) GetLabelGrouping() []string {
+	return c.labelGrouping()
+}
+
 func (c *BudgetConfig) GetTotals(log types.Log) (Totals, error) {
+	switch p := c.aggregationPeriod(); p {
+	case Daily, Weekly, Monthly, Quarterly, Yearly:
+	default:
+		return nil, fmt.Errorf("unknown period %q: want Daily, Weekly, Monthly, Quarterly or Yearly", p)
+	}
+	if c.aggregationPeriod() == Daily {
+		totals, err := c.getDailyTotals(log)
+		if err != nil {
+			return nil, err
+		}
+		c.applyTargets(totals)
+		return totals, nil
+	}
 	totals := make(Totals, 0)
 	for _, week := range log {
 		total, err := c.getTotal(week)
@@ -113,17 +154,115 @@ func (c *BudgetConfig) GetTotals(log types.Log) (Totals, error) {
 			}
 			totals = append(totals, t)
 		}
+		sort.Slice(totals, func(i, j int) bool { return totals[i].Date.Before(totals[j].Date) })
 	}
+	c.applyTargets(totals)
 	return totals, nil
 }
 
+// getDailyTotals budgets each day of entries in day sections. A day's
+// absolute time is a working day, see dayAbsolute. Entries without a day
+// are left out. Holidays and PTO are taken out of their day, or accounted
+// for as a category like in weekly totals.
+func (c *BudgetConfig) getDailyTotals(log types.Log) (Totals, error) {
+	totals := make(Totals, 0)
+	for _, week := range log {
+		away, err := c.weekDaysAway(week)
+		if err != nil {
+			return nil, err
+		}
+		days := []time.Time{}
+		doneByDay := map[time.Time][]*types.Entry{}
+		for _, entry := range week.Done {
+			if entry.Date.IsZero() {
+				continue
+			}
+			if _, ok := doneByDay[entry.Date]; !ok {
+				days = append(days, entry.Date)
+			}
+			doneByDay[entry.Date] = append(doneByDay[entry.Date], entry)
+		}
+		absolute, err := c.dayAbsolute(week, len(days))
+		if err != nil {
+			return nil, err
+		}
+		if c.timeAwayEntries() {
+			for _, day := range away {
+				if _, ok := doneByDay[day]; !ok {
+					days = append(days, day)
+				}
+			}
+		}
+		for _, day := range days {
+			dayAbsolute := absolute
+			done := doneByDay[day]
+			if containsDate(away, day) {
+				if c.timeAwayEntries() {
+					done = append(append([]*types.Entry{}, done...), c.timeAwayEntry(absolute))
+				} else {
+					dayAbsolute = 0
+				}
+			}
+			total := &Total{
+				Date:      day,
+				Period:    Daily,
+				Absolute:  dayAbsolute,
+				SubTotals: []*SubTotal{},
+			}
+			if dayAbsolute > 0 {
+				subTotals, compressionRatio, err := c.getSubTotals(1, 0, 1.0, dayAbsolute, shares(done))
+				if err != nil {
+					return nil, err
+				}
+				total.SubTotals = subTotals
+				total.Ratio = compressionRatio
+			}
+			totals = append(totals, total)
+		}
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Date.Before(totals[j].Date) })
+	return totals, nil
+}
+
+func containsDate(dates []time.Time, t time.Time) bool {
+	for _, d := range dates {
+		if d.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *BudgetConfig) getTotal(week *types.Week) (*Total, error) {
+	absolute, err := c.weekAbsolute(week)
+	if err != nil {
+		return nil, err
+	}
+	done := week.Done
+	away, err := c.weekTimeAway(week)
+	if err != nil {
+		return nil, err
+	}
+	if away > 0 {
+		// Either account for time away as a category or take it out of
+		// the week.
+		if c.timeAwayEntries() {
+			done = append(append([]*types.Entry{}, done...), c.timeAwayEntry(away))
+		} else {
+			absolute -= away
+		}
+	}
 	total := &Total{
-		Date:     week.Date,
-		Period:   Weekly,
-		Absolute: time.Duration(c.daysPerWeek()) * time.Duration(c.hoursPerDay()) * time.Hour,
+		Date:      week.Date,
+		Period:    Weekly,
+		Absolute:  absolute,
+		SubTotals: []*SubTotal{},
+	}
+	if absolute == 0 {
+		// Nothing to distribute in a week without working time.
+		return total, nil
 	}
-	subTotals, compressionRatio, err := c.getSubTotals(1, 1.0, total.Absolute, week.Done)
+	subTotals, compressionRatio, err := c.getSubTotals(1, 0, 1.0, total.Absolute, shares(done))
 	if err != nil {
 		return nil, err
 	}
@@ -132,7 +271,84 @@ func (c *BudgetConfig) getTotal(week *types.Week) (*Total, error) {
 	return total, nil
 }
 
-func (c *BudgetConfig) getSubTotals(groupingLevel int, relative float64, absolute time.Duration, done []*types.Entry) ([]*SubTotal, float64, error) {
+// weekAbsolute returns the working time of a week. The configured days
+// per week and hours per day can be overridden for a single week with a
+// "Days" or "Hours" header (e.g. "Days: 3" for a holiday week or
+// "Hours: 52" for an on-call week). Hours takes precedence over Days.
+func (c *BudgetConfig) weekAbsolute(week *types.Week) (time.Duration, error) {
+	hoursPerDay := time.Duration(c.hoursPerDay()) * time.Hour
+	absolute := time.Duration(c.daysPerWeek()) * hoursPerDay
+	if days, ok, err := headerFloat(week, daysHeader); err != nil {
+		return 0, err
+	} else if ok {
+		absolute = time.Duration(days * float64(hoursPerDay))
+	}
+	if hours, ok, err := headerFloat(week, hoursHeader); err != nil {
+		return 0, err
+	} else if ok {
+		absolute = time.Duration(hours * float64(time.Hour))
+	}
+	return absolute, nil
+}
+
+// dayAbsolute returns the working time of each day of a week in which
+// entries are dated to worked days. A day has the configured hours per
+// day, also in a week with a "Days" header. A week with an "Hours" header
+// spreads its hours evenly over the days worked.
+func (c *BudgetConfig) dayAbsolute(week *types.Week, worked int) (time.Duration, error) {
+	if _, _, err := headerFloat(week, daysHeader); err != nil {
+		return 0, err
+	}
+	hours, ok, err := headerFloat(week, hoursHeader)
+	if err != nil {
+		return 0, err
+	}
+	if ok && worked > 0 {
+		return time.Duration(hours*float64(time.Hour)) / time.Duration(worked), nil
+	}
+	return time.Duration(c.hoursPerDay()) * time.Hour, nil
+}
+
+func headerFloat(week *types.Week, key string) (float64, bool, error) {
+	vs, ok := week.Header[key]
+	if !ok || len(vs) == 0 {
+		return 0, false, nil
+	}
+	if len(vs) > 1 {
+		return 0, false, fmt.Errorf("duplicate %q header in week of %v", key, week.Date.Format("Jan 02 2006"))
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(vs[0]), 64)
+	if err != nil || f < 0 {
+		return 0, false, fmt.Errorf("malformed %q header in week of %v: %q", key, week.Date.Format("Jan 02 2006"), vs[0])
+	}
+	return f, true, nil
+}
+
+// share is the part of a done entry which is budgeted to one value of a
+// grouping label. An entry whose grouping label has several values, like
+// "cat=customer,primary", is split into an equal share for each value.
+type share struct {
+	entry  *types.Entry
+	weight float64
+	// value is the label value the share is for while grouping on the
+	// segments of a path. It is empty before the label is split.
+	value string
+}
+
+func shares(done []*types.Entry) []*share {
+	s := make([]*share, len(done))
+	for i, entry := range done {
+		s[i] = &share{entry: entry, weight: 1.0}
+	}
+	return s
+}
+
+// getSubTotals groups done entries by the label at groupingLevel. Label
+// values can be paths like "primary/thing-one/design", in which case depth
+// is the number of path segments already grouped on. Each segment of a
+// path is nested under the one before it, down to the end of the longest
+// path, before grouping on the next label.
+func (c *BudgetConfig) getSubTotals(groupingLevel, depth int, relative float64, absolute time.Duration, done []*share) ([]*SubTotal, float64, error) {
 	if groupingLevel > len(c.labelGrouping()) {
 		return []*SubTotal{}, 0, nil
 	}
@@ -142,71 +358,105 @@ func (c *BudgetConfig) getSubTotals(groupingLevel int, relative float64, absolut
 		return nil, 0, err
 	}
 	subTotalsByValue := map[string]*SubTotal{}
-	doneByValue := map[string][]*types.Entry{}
+	doneByValue := map[string][]*share{}
+	counted := map[string]map[*types.Entry]bool{}
+	deeper := map[string]bool{}
 	for _, entry := range entryTimes {
-		value, ok := entry.entry.Labels[key]
-		if !ok {
-			value = ""
+		values := []string{entry.share.value}
+		if depth == 0 {
+			values = []string{""}
+			if v, ok := entry.share.entry.Labels[key]; ok {
+				values = types.LabelValues(v)
+			}
 		}
-		s, ok := subTotalsByValue[value]
-		if !ok {
-			s = &SubTotal{
-				Label:     key,
-				Value:     value,
-				SubTotals: []*SubTotal{},
+		fraction := 1.0 / float64(len(values))
+		for _, v := range values {
+			path := labelPath(v)
+			value := ""
+			prefix := path
+			if depth < len(path) {
+				value = path[depth]
+				prefix = path[:depth+1]
+			}
+			s, ok := subTotalsByValue[value]
+			if !ok {
+				s = &SubTotal{
+					Label:     key,
+					Value:     value,
+					Path:      strings.Join(prefix, "/"),
+					SubTotals: []*SubTotal{},
+				}
+				subTotalsByValue[value] = s
+				counted[value] = map[*types.Entry]bool{}
+			}
+			s.Relative += entry.relative * fraction
+			s.Absolute += time.Duration(float64(entry.strict+entry.fuzzy) * fraction)
+			if !counted[value][entry.share.entry] {
+				s.Count += 1
+				counted[value][entry.share.entry] = true
+			}
+			doneByValue[value] = append(doneByValue[value], &share{
+				entry:  entry.share.entry,
+				weight: entry.share.weight * fraction,
+				value:  v,
+			})
+			if depth+1 < len(path) {
+				deeper[value] = true
 			}
-			subTotalsByValue[value] = s
 		}
-		s.Relative += entry.relative
-		s.Absolute += entry.strict + entry.fuzzy
-		s.Count += 1
-		doneByValue[value] = append(doneByValue[value], entry.entry)
 	}
 	subTotals := []*SubTotal{}
 	for _, s := range subTotalsByValue {
-		ss, _, err := c.getSubTotals(groupingLevel+1, s.Relative, s.Absolute, doneByValue[s.Value])
+		level, nextDepth := groupingLevel+1, 0
+		if deeper[s.Value] {
+			level, nextDepth = groupingLevel, depth+1
+		}
+		ss, _, err := c.getSubTotals(level, nextDepth, s.Relative, s.Absolute, doneByValue[s.Value])
 		if err != nil {
 			return nil, 0, err
 		}
 		s.SubTotals = ss
 		subTotals = append(subTotals, s)
 	}
+	sortByValue(subTotals)
 	return subTotals, compressionRatio, nil
 }
 
+// labelPath splits a label value into its path segments.
+func labelPath(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, "/")
+}
+
 type entryTime struct {
-	entry    *types.Entry
+	share    *share
 	relative float64
 	strict   time.Duration
 	fuzzy    time.Duration
 }
 
-func (c *BudgetConfig) entryTimes(relative float64, absolute time.Duration, done []*types.Entry) (entryTimes []*entryTime, compressionRatio float64, err error) {
+func (c *BudgetConfig) entryTimes(relative float64, absolute time.Duration, done []*share) (entryTimes []*entryTime, compressionRatio float64, err error) {
 	if len(done) == 0 {
 		return
 	}
 	var strictTotal time.Duration
 	var fuzzyTotal time.Duration
 	compressionRatio = 1.0
-	for _, entry := range done {
+	for _, s := range done {
 		et := &entryTime{
-			entry: entry,
-		}
-		if f, ok := entry.Labels["f"]; ok {
-			et.fuzzy, err = time.ParseDuration(f)
-			if err != nil {
-				return nil, 0, fmt.Errorf("malformed 'f': %v", err)
-			}
+			share: s,
 		}
-		if t, ok := entry.Labels["t"]; ok {
-			et.strict, err = time.ParseDuration(t)
-			if err != nil {
-				return nil, 0, fmt.Errorf("malformed 't': %v", err)
-			}
+		et.strict, et.fuzzy, err = parseEntryTime(s.entry)
+		if err != nil {
+			return nil, 0, err
 		}
 		if et.strict == 0 && et.fuzzy == 0 {
 			et.fuzzy = time.Duration(c.minutesPerEntry()) * time.Minute
 		}
+		et.strict = time.Duration(float64(et.strict) * s.weight)
+		et.fuzzy = time.Duration(float64(et.fuzzy) * s.weight)
 		strictTotal += et.strict
 		fuzzyTotal += et.fuzzy
 		entryTimes = append(entryTimes, et)
@@ -239,28 +489,112 @@ func (c *BudgetConfig) entryTimes(relative float64, absolute time.Duration, done
 	return
 }
 
+// ValidateEntry checks that the time labels of entry can be budgeted.
+func (c *BudgetConfig) ValidateEntry(entry *types.Entry) error {
+	_, _, err := parseEntryTime(entry)
+	return err
+}
+
+func parseEntryTime(entry *types.Entry) (strict, fuzzy time.Duration, err error) {
+	if f, ok := entry.Labels["f"]; ok {
+		fuzzy, err = time.ParseDuration(f)
+		if err != nil {
+			return 0, 0, fmt.Errorf("malformed 'f': %v", err)
+		}
+	}
+	if t, ok := entry.Labels["t"]; ok {
+		strict, err = time.ParseDuration(t)
+		if err != nil {
+			return 0, 0, fmt.Errorf("malformed 't': %v", err)
+		}
+	}
+	return strict, fuzzy, nil
+}
+
+// groupTotals buckets weekly totals by aggregation period. A week whose
+// working days fall into two periods (e.g. a week spanning the end of a
+// month) is split between them in proportion to its working days.
 func (c *BudgetConfig) groupTotals(totals Totals) (map[time.Time]Totals, error) {
 	totalsByTime := map[time.Time]Totals{}
 	for _, total := range totals {
-		t := c.roundToPeriod(total.Date)
-		totalsByTime[t] = append(totalsByTime[t], total)
+		for t, split := range c.splitTotal(total) {
+			totalsByTime[t] = append(totalsByTime[t], split)
+		}
 	}
 	return totalsByTime, nil
 }
 
+// splitTotal divides a weekly total between the periods containing its
+// working days. Working days are counted from the week's date.
+func (c *BudgetConfig) splitTotal(total *Total) map[time.Time]*Total {
+	days := c.daysPerWeek()
+	if days < 1 {
+		days = 1
+	}
+	daysByPeriod := map[time.Time]int{}
+	for i := 0; i < days; i++ {
+		t := c.roundToPeriod(total.Date.AddDate(0, 0, i))
+		daysByPeriod[t]++
+	}
+	splits := map[time.Time]*Total{}
+	for t, n := range daysByPeriod {
+		if n == days {
+			splits[t] = total
+			continue
+		}
+		splits[t] = total.scale(float64(n) / float64(days))
+	}
+	return splits
+}
+
+// roundToPeriod returns the start of the calendar period containing t.
+// Weeks are ISO weeks, starting on Monday.
 func (c *BudgetConfig) roundToPeriod(t time.Time) time.Time {
+	year, month, day := t.Date()
 	switch c.aggregationPeriod() {
 	case Weekly:
-		return t.Truncate(7 * 24 * time.Hour)
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
 	case Monthly:
-		return t.Truncate(30 * 24 * time.Hour)
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
 	case Quarterly:
-		return t.Truncate(90 * 24 * time.Hour)
+		quarter := (month - 1) / 3
+		return time.Date(year, quarter*3+1, 1, 0, 0, 0, 0, t.Location())
+	case Yearly:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
 	default:
 		return t
 	}
 }
 
+// scale returns a copy of the total with absolute time and counts scaled
+// by fraction. Relative values are unchanged.
+func (t *Total) scale(fraction float64) *Total {
+	return &Total{
+		Date:      t.Date,
+		Period:    t.Period,
+		Absolute:  time.Duration(float64(t.Absolute) * fraction),
+		SubTotals: SubTotals(t.SubTotals).scale(fraction),
+		Ratio:     t.Ratio,
+	}
+}
+
+func (ss SubTotals) scale(fraction float64) SubTotals {
+	scaled := make(SubTotals, 0, len(ss))
+	for _, s := range ss {
+		scaled = append(scaled, &SubTotal{
+			Label:     s.Label,
+			Value:     s.Value,
+			Path:      s.Path,
+			Relative:  s.Relative,
+			Absolute:  time.Duration(float64(s.Absolute) * fraction),
+			Count:     int(math.Round(float64(s.Count) * fraction)),
+			SubTotals: s.SubTotals.scale(fraction),
+		})
+	}
+	return scaled
+}
+
 func (ts Totals) mergeOn(date time.Time, period Period) (*Total, error) {
 	if len(ts) == 0 {
 		return nil, fmt.Errorf("Cannot merge empty Totals.")
@@ -274,7 +608,7 @@ func (ts Totals) mergeOn(date time.Time, period Period) (*Total, error) {
 		total.Absolute = total.Absolute + t.Absolute
 		ss = append(ss, t.SubTotals)
 	}
-	s, err := mergeByValue(ss, len(ts))
+	s, err := mergeByValue(ss, total.Absolute)
 	if err != nil {
 		return nil, err
 	}
@@ -282,7 +616,10 @@ func (ts Totals) mergeOn(date time.Time, period Period) (*Total, error) {
 	return total, nil
 }
 
-func mergeByValue(sss []SubTotals, lenTotals int) (SubTotals, error) {
+// mergeByValue merges SubTotals with the same value. Relative values are
+// recomputed against absolute, the total time of the merged period, so
+// that partial weeks are weighted by the time they contribute.
+func mergeByValue(sss []SubTotals, absolute time.Duration) (SubTotals, error) {
 	subTotals := make(SubTotals, 0)
 	var label string
 	subTotalsByValue := map[string]SubTotals{}
@@ -299,16 +636,17 @@ func mergeByValue(sss []SubTotals, lenTotals int) (SubTotals, error) {
 		}
 	}
 	for _, ss := range subTotalsByValue {
-		s, err := ss.merge(lenTotals)
+		s, err := ss.merge(absolute)
 		if err != nil {
 			return nil, err
 		}
 		subTotals = append(subTotals, s)
 	}
+	sortByValue(subTotals)
 	return subTotals, nil
 }
 
-func (ss SubTotals) merge(lenTotals int) (*SubTotal, error) {
+func (ss SubTotals) merge(absolute time.Duration) (*SubTotal, error) {
 	if len(ss) == 0 {
 		return nil, fmt.Errorf("Cannot merge empty SubTotals.")
 	}
@@ -316,6 +654,7 @@ func (ss SubTotals) merge(lenTotals int) (*SubTotal, error) {
 	subTotal := &SubTotal{
 		Label: label,
 		Value: value,
+		Path:  ss[0].Path,
 	}
 	subSubTotals := make([]SubTotals, 0)
 	for _, s := range ss {
@@ -327,43 +666,19 @@ func (ss SubTotals) merge(lenTotals int) (*SubTotal, error) {
 		}
 		subTotal.Absolute += s.Absolute
 		subTotal.Count += s.Count
-		subTotal.Relative += s.Relative
 		subSubTotals = append(subSubTotals, s.SubTotals)
 	}
-	subTotals, err := mergeByValue(subSubTotals, lenTotals)
+	subTotals, err := mergeByValue(subSubTotals, absolute)
 	if err != nil {
 		return nil, err
 	}
-	subTotal.Relative = subTotal.Relative / float64(lenTotals)
+	if absolute != 0 {
+		subTotal.Relative = float64(subTotal.Absolute) / float64(absolute)
+	}
 	subTotal.SubTotals = subTotals
 	return subTotal, nil
 }
 
-func (ts Totals) Focus(value string) (Totals, error) {
-	focusedTotals := make(Totals, 0)
-	for _, t := range ts {
-		focusedTotal := &Total{
-			Date:   t.Date,
-			Period: t.Period,
-		}
-		focusedSubTotals := make(SubTotals, 0)
-		for _, s := range t.SubTotals {
-			if s.Value == value {
-				for _, ss := range s.SubTotals {
-					fs := &SubTotal{
-						Label:    ss.Label,
-						Value:    ss.Value,
-						Relative: ss.Relative / s.Relative,
-						Absolute: ss.Absolute,
-						Count:    ss.Count,
-					}
-					focusedTotal.Absolute += ss.Absolute
-					focusedSubTotals = append(focusedSubTotals, fs)
-				}
-			}
-		}
-		focusedTotal.SubTotals = focusedSubTotals
-		focusedTotals = append(focusedTotals, focusedTotal)
-	}
-	return focusedTotals, nil
+func sortByValue(ss []*SubTotal) {
+	sort.Slice(ss, func(i, j int) bool { return ss[i].Value < ss[j].Value })
 }
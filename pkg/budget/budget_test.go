@@ -2,7 +2,10 @@ package budget
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -238,7 +241,7 @@ func TestTotalsMergeOn(t *testing.T) {
 func TestSubTotalsMerge(t *testing.T) {
 	cases := []struct {
 		name         string
-		lenTotals    int
+		absolute     time.Duration
 		subTotals    []*SubTotal
 		wantSubTotal *SubTotal
 		wantError    bool
@@ -265,7 +268,8 @@ func TestSubTotalsMerge(t *testing.T) {
 		},
 		wantError: true,
 	}, {
-		name: "merge compatible sub totals",
+		name:     "merge compatible sub totals",
+		absolute: 2 * time.Hour,
 		subTotals: []*SubTotal{{
 			Label:    "a",
 			Value:    "1",
@@ -291,11 +295,7 @@ func TestSubTotalsMerge(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			subTotals := SubTotals(c.subTotals)
-			lenTotals := c.lenTotals
-			if c.lenTotals == 0 {
-				lenTotals = 1
-			}
-			got, err := subTotals.merge(lenTotals)
+			got, err := subTotals.merge(c.absolute)
 			if c.wantError && err == nil {
 				t.Errorf("Wanted error. Got none.")
 			}
@@ -393,7 +393,7 @@ func TestEntryTimes(t *testing.T) {
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			entryTimes, err := (*BudgetConfig)(nil).entryTimes(c.relative, c.absolute, c.done)
+			entryTimes, _, err := (*BudgetConfig)(nil).entryTimes(c.relative, c.absolute, shares(c.done))
 			if err != nil && !c.wantErr {
 				t.Errorf("wanted no error. got %v", err)
 			}
@@ -419,6 +419,90 @@ func TestEntryTimes(t *testing.T) {
 	}
 }
 
+func TestRoundToPeriod(t *testing.T) {
+	cases := []struct {
+		name   string
+		period Period
+		date   time.Time
+		want   time.Time
+	}{{
+		name:   "weekly starts on monday",
+		period: Weekly,
+		date:   date(2020, time.November, 29),
+		want:   date(2020, time.November, 23),
+	}, {
+		name:   "monthly",
+		period: Monthly,
+		date:   date(2020, time.November, 23),
+		want:   date(2020, time.November, 1),
+	}, {
+		name:   "quarterly",
+		period: Quarterly,
+		date:   date(2020, time.November, 23),
+		want:   date(2020, time.October, 1),
+	}, {
+		name:   "yearly",
+		period: Yearly,
+		date:   date(2020, time.November, 23),
+		want:   date(2020, time.January, 1),
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bc := &BudgetConfig{AggregationPeriod: &c.period}
+			got := bc.roundToPeriod(c.date)
+			if !got.Equal(c.want) {
+				t.Errorf("wanted %v. got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestGroupTotalsSplitsWeeks(t *testing.T) {
+	period := Monthly
+	bc := &BudgetConfig{AggregationPeriod: &period}
+	// Wednesday Sep 30 2020: three working days in September, two in October.
+	total := &Total{
+		Date:     date(2020, time.September, 28),
+		Period:   Weekly,
+		Absolute: 40 * time.Hour,
+		SubTotals: []*SubTotal{{
+			Label:    "cat",
+			Value:    "a",
+			Relative: 1.0,
+			Absolute: 40 * time.Hour,
+			Count:    5,
+		}},
+	}
+	groups, err := bc.groupTotals(Totals{total})
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	want := map[time.Time]time.Duration{
+		date(2020, time.September, 1): 24 * time.Hour,
+		date(2020, time.October, 1):   16 * time.Hour,
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("wanted %v groups. got %v", len(want), len(groups))
+	}
+	for d, absolute := range want {
+		ts := groups[d]
+		if len(ts) != 1 {
+			t.Fatalf("wanted 1 total for %v. got %v", d, len(ts))
+		}
+		if ts[0].Absolute != absolute {
+			t.Errorf("wanted absolute %v for %v. got %v", absolute, d, ts[0].Absolute)
+		}
+		if got := ts[0].SubTotals[0].Absolute; got != absolute {
+			t.Errorf("wanted sub total absolute %v for %v. got %v", absolute, d, got)
+		}
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
 func entry(strict, fuzzy string) *types.Entry {
 	labels := map[string]string{}
 	if strict != "" {
@@ -499,3 +583,480 @@ func (s1 *SubTotal) equal(s2 *SubTotal) bool {
 		return true
 	}
 }
+
+func TestGetViolations(t *testing.T) {
+	min, max := 0.3, 0.1
+	bc := &BudgetConfig{
+		Targets: []*Target{{
+			Value: "primary",
+			Min:   &min,
+		}, {
+			Value: "business",
+			Max:   &max,
+		}, {
+			Value: "customer",
+			Max:   &max,
+		}},
+	}
+	week := &types.Week{
+		Date: date(2020, time.November, 23),
+		Done: []*types.Entry{
+			{Labels: map[string]string{"cat": "primary", "f": "1h"}},
+			{Labels: map[string]string{"cat": "business", "f": "3h"}},
+		},
+	}
+	totals, err := bc.GetTotals(types.Log{week})
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	violations := bc.GetViolations(totals)
+	if len(violations) != 2 {
+		t.Fatalf("wanted 2 violations. got %v", len(violations))
+	}
+	want := map[string]float64{
+		"primary":  0.25 - 0.3,
+		"business": 0.75 - 0.1,
+	}
+	for _, v := range violations {
+		if math.Abs(v.Variance-want[v.Value]) > 1e-9 {
+			t.Errorf("wanted %v variance %v. got %v", v.Value, want[v.Value], v.Variance)
+		}
+	}
+	for _, s := range totals[0].SubTotals {
+		if math.Abs(s.Variance-want[s.Value]) > 1e-9 {
+			t.Errorf("wanted %v sub total variance %v. got %v", s.Value, want[s.Value], s.Variance)
+		}
+	}
+}
+
+func TestFocusPath(t *testing.T) {
+	totals := Totals{{
+		Date:     time.Unix(0, 0),
+		Period:   Weekly,
+		Absolute: 40 * time.Hour,
+		SubTotals: []*SubTotal{{
+			Label:    "cat",
+			Value:    "a",
+			Relative: 0.5,
+			Absolute: 20 * time.Hour,
+			SubTotals: []*SubTotal{{
+				Label:    "sub",
+				Value:    "x",
+				Relative: 0.5,
+				Absolute: 20 * time.Hour,
+				SubTotals: []*SubTotal{{
+					Label:    "proj",
+					Value:    "1",
+					Relative: 0.125,
+					Absolute: 5 * time.Hour,
+				}, {
+					Label:    "proj",
+					Value:    "2",
+					Relative: 0.375,
+					Absolute: 15 * time.Hour,
+				}},
+			}},
+		}, {
+			Label:    "cat",
+			Value:    "x",
+			Relative: 0.5,
+			Absolute: 20 * time.Hour,
+		}},
+	}}
+	cases := []struct {
+		name string
+		path string
+		want *Total
+	}{{
+		name: "value only",
+		path: "a",
+		want: &Total{
+			Date:     time.Unix(0, 0),
+			Period:   Weekly,
+			Absolute: 20 * time.Hour,
+			SubTotals: []*SubTotal{{
+				Label:    "sub",
+				Value:    "x",
+				Relative: 1.0,
+				Absolute: 20 * time.Hour,
+				SubTotals: []*SubTotal{{
+					Label:    "proj",
+					Value:    "1",
+					Relative: 0.25,
+					Absolute: 5 * time.Hour,
+				}, {
+					Label:    "proj",
+					Value:    "2",
+					Relative: 0.75,
+					Absolute: 15 * time.Hour,
+				}},
+			}},
+		},
+	}, {
+		name: "two levels",
+		path: "cat=a/sub=x",
+		want: &Total{
+			Date:     time.Unix(0, 0),
+			Period:   Weekly,
+			Absolute: 20 * time.Hour,
+			SubTotals: []*SubTotal{{
+				Label:    "proj",
+				Value:    "1",
+				Relative: 0.25,
+				Absolute: 5 * time.Hour,
+			}, {
+				Label:    "proj",
+				Value:    "2",
+				Relative: 0.75,
+				Absolute: 15 * time.Hour,
+			}},
+		},
+	}, {
+		name: "label must match",
+		path: "sub=a",
+		want: &Total{
+			Date:   time.Unix(0, 0),
+			Period: Weekly,
+		},
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := totals.Focus(c.path)
+			if err != nil {
+				t.Fatalf("wanted no error. got %v", err)
+			}
+			if len(got) != 1 || !got[0].equal(c.want) {
+				b, _ := json.Marshal(got)
+				t.Errorf("wanted %+v. got %v", c.want, string(b))
+			}
+		})
+	}
+}
+
+func TestWeekAbsolute(t *testing.T) {
+	cases := []struct {
+		name    string
+		header  map[string][]string
+		want    time.Duration
+		wantErr bool
+	}{{
+		name: "default",
+		want: 40 * time.Hour,
+	}, {
+		name:   "days",
+		header: map[string][]string{"Days": {"3"}},
+		want:   24 * time.Hour,
+	}, {
+		name:   "half days",
+		header: map[string][]string{"Days": {"2.5"}},
+		want:   20 * time.Hour,
+	}, {
+		name:   "hours take precedence",
+		header: map[string][]string{"Days": {"3"}, "Hours": {"52"}},
+		want:   52 * time.Hour,
+	}, {
+		name:    "malformed",
+		header:  map[string][]string{"Hours": {"lots"}},
+		wantErr: true,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			week := &types.Week{Header: c.header}
+			got, err := (*BudgetConfig)(nil).weekAbsolute(week)
+			if c.wantErr && err == nil {
+				t.Errorf("wanted error. got none")
+			}
+			if !c.wantErr && err != nil {
+				t.Errorf("wanted no error. got %v", err)
+			}
+			if got != c.want {
+				t.Errorf("wanted %v. got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestTimeAway(t *testing.T) {
+	week := &types.Week{
+		Date: date(2020, time.December, 21),
+		Done: []*types.Entry{
+			{Labels: map[string]string{"cat": "primary"}},
+		},
+	}
+	yes := true
+	cases := []struct {
+		name     string
+		config   *BudgetConfig
+		absolute time.Duration
+		values   []string
+	}{{
+		name: "holidays and pto are taken out",
+		config: &BudgetConfig{
+			Holidays: []string{"2020-12-25", "2020-12-26"},
+			TimeAway: []*DateRange{{From: "2020-12-23", To: "2020-12-24"}},
+		},
+		absolute: 16 * time.Hour,
+		values:   []string{"primary"},
+	}, {
+		name: "time away entries",
+		config: &BudgetConfig{
+			Holidays:        []string{"2020-12-25"},
+			TimeAwayEntries: &yes,
+		},
+		absolute: 40 * time.Hour,
+		values:   []string{"primary", "time-away"},
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := c.config.getTotal(week)
+			if err != nil {
+				t.Fatalf("wanted no error. got %v", err)
+			}
+			if got.Absolute != c.absolute {
+				t.Errorf("wanted absolute %v. got %v", c.absolute, got.Absolute)
+			}
+			values := []string{}
+			for _, s := range got.SubTotals {
+				values = append(values, s.Value)
+			}
+			if strings.Join(values, ",") != strings.Join(c.values, ",") {
+				t.Errorf("wanted values %v. got %v", c.values, values)
+			}
+		})
+	}
+}
+
+func TestHierarchicalLabels(t *testing.T) {
+	c := &BudgetConfig{LabelGrouping: []string{"cat", "sub"}}
+	done := []*types.Entry{}
+	for _, labels := range []map[string]string{
+		{"cat": "primary/thing-one/design"},
+		{"cat": "primary/thing-one", "sub": "ops"},
+		{"cat": "primary"},
+		{"cat": "community"},
+	} {
+		done = append(done, &types.Entry{Labels: labels})
+	}
+	subTotals, _, err := c.getSubTotals(1, 0, 1.0, 40*time.Hour, shares(done))
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	// Each SubTotal as label=value, with its children in brackets.
+	var sprint func(ss []*SubTotal) string
+	sprint = func(ss []*SubTotal) string {
+		out := ""
+		for _, s := range ss {
+			out += fmt.Sprintf(" %v=%v(%v)", s.Label, s.Value, s.Count)
+			if len(s.SubTotals) > 0 {
+				out += " [" + sprint(s.SubTotals) + " ]"
+			}
+		}
+		return out
+	}
+	want := " cat=community(1) [ sub=(1) ] cat=primary(3) [ cat=(1) [ sub=(1) ] cat=thing-one(2) [ cat=(1) [ sub=ops(1) ] cat=design(1) [ sub=(1) ] ] ]"
+	if got := sprint(subTotals); got != want {
+		t.Errorf("wanted %q. got %q", want, got)
+	}
+	totals := Totals{{SubTotals: subTotals}}
+	focused, err := totals.Focus("cat=primary/thing-one")
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if got := sprint(focused[0].SubTotals); got != " cat=(1) [ sub=ops(1) ] cat=design(1) [ sub=(1) ]" {
+		t.Errorf("wanted the thing-one subtotals. got %q", got)
+	}
+}
+
+func TestMultiValuedLabels(t *testing.T) {
+	c := &BudgetConfig{LabelGrouping: []string{"cat", "sub"}}
+	done := []*types.Entry{
+		{Labels: map[string]string{"cat": "customer,primary", "sub": "a,b", "f": "1h"}},
+		{Labels: map[string]string{"cat": "primary", "sub": "a", "f": "2h"}},
+	}
+	subTotals, _, err := c.getSubTotals(1, 0, 1.0, 40*time.Hour, shares(done))
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	// Values with their relative share as a percentage.
+	var sprint func(ss []*SubTotal) string
+	sprint = func(ss []*SubTotal) string {
+		out := ""
+		for _, s := range ss {
+			out += fmt.Sprintf(" %v=%v(%.1f)", s.Label, s.Value, s.Relative*100)
+			if len(s.SubTotals) > 0 {
+				out += " [" + sprint(s.SubTotals) + " ]"
+			}
+		}
+		return out
+	}
+	want := " cat=customer(16.7) [ sub=a(8.3) sub=b(8.3) ] cat=primary(83.3) [ sub=a(75.0) sub=b(8.3) ]"
+	if got := sprint(subTotals); got != want {
+		t.Errorf("wanted %q. got %q", want, got)
+	}
+}
+
+func TestPathTargets(t *testing.T) {
+	min, max := 0.1, 0.1
+	c := &BudgetConfig{
+		LabelGrouping: []string{"cat"},
+		Targets: []*Target{
+			{Value: "primary", Max: &max},
+			{Value: "primary/x", Max: &max},
+			{Value: "community/design", Max: &max},
+			{Value: "design", Min: &min},
+		},
+	}
+	done := []*types.Entry{
+		{Labels: map[string]string{"cat": "primary/x/design", "f": "1h"}},
+		{Labels: map[string]string{"cat": "community/design", "f": "1h"}},
+		{Labels: map[string]string{"cat": "primary", "f": "2h"}},
+	}
+	subTotals, _, err := c.getSubTotals(1, 0, 1.0, 40*time.Hour, shares(done))
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	got := map[string]float64{}
+	for _, v := range c.GetViolations(Totals{{SubTotals: subTotals}}) {
+		got[v.Value] = v.Relative
+	}
+	want := map[string]float64{
+		"primary":          0.75,
+		"primary/x":        0.25,
+		"community/design": 0.25,
+		"design":           0,
+	}
+	if len(got) != len(want) {
+		t.Errorf("wanted violations %v. got %v", want, got)
+	}
+	for k, v := range want {
+		if r, ok := got[k]; !ok || math.Abs(r-v) > 0.0001 {
+			t.Errorf("wanted %v at %v. got %v", k, v, r)
+		}
+	}
+}
+
+func TestDailyTimeAway(t *testing.T) {
+	week := &types.Week{
+		Date: date(2020, time.December, 21),
+		Done: []*types.Entry{
+			{Date: date(2020, time.December, 24), Labels: map[string]string{"cat": "primary"}},
+			{Date: date(2020, time.December, 25), Labels: map[string]string{"cat": "primary"}},
+		},
+	}
+	daily := Daily
+	yes := true
+	cases := []struct {
+		name   string
+		config *BudgetConfig
+		want   []string
+	}{{
+		name: "holidays are taken out",
+		config: &BudgetConfig{
+			AggregationPeriod: &daily,
+			Holidays:          []string{"2020-12-25"},
+		},
+		want: []string{"2020-12-24 8h0m0s primary", "2020-12-25 0s"},
+	}, {
+		name: "time away entries",
+		config: &BudgetConfig{
+			AggregationPeriod: &daily,
+			Holidays:          []string{"2020-12-23", "2020-12-25"},
+			TimeAwayEntries:   &yes,
+		},
+		want: []string{
+			"2020-12-23 8h0m0s time-away",
+			"2020-12-24 8h0m0s primary",
+			"2020-12-25 8h0m0s primary,time-away",
+		},
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			totals, err := c.config.GetTotals(types.Log{week})
+			if err != nil {
+				t.Fatalf("wanted no error. got %v", err)
+			}
+			got := []string{}
+			for _, total := range totals {
+				values := []string{}
+				for _, s := range total.SubTotals {
+					values = append(values, s.Value)
+				}
+				got = append(got, strings.TrimSpace(fmt.Sprintf("%v %v %v",
+					total.Date.Format("2006-01-02"), total.Absolute, strings.Join(values, ","))))
+			}
+			if strings.Join(got, "\n") != strings.Join(c.want, "\n") {
+				t.Errorf("wanted %v. got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestDailyAbsolute(t *testing.T) {
+	daily := Daily
+	zero := 0
+	cases := []struct {
+		name   string
+		config *BudgetConfig
+		header map[string][]string
+		want   time.Duration
+	}{{
+		name:   "working day",
+		config: &BudgetConfig{AggregationPeriod: &daily},
+		want:   8 * time.Hour,
+	}, {
+		name:   "days header",
+		config: &BudgetConfig{AggregationPeriod: &daily},
+		header: map[string][]string{"Days": {"3"}},
+		want:   8 * time.Hour,
+	}, {
+		name:   "hours header spread over days worked",
+		config: &BudgetConfig{AggregationPeriod: &daily},
+		header: map[string][]string{"Hours": {"30"}},
+		want:   10 * time.Hour,
+	}, {
+		name:   "no days per week",
+		config: &BudgetConfig{AggregationPeriod: &daily, DaysPerWeek: &zero},
+		want:   8 * time.Hour,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			week := &types.Week{
+				Date:   date(2020, time.November, 23),
+				Header: c.header,
+			}
+			for i := 0; i < 3; i++ {
+				week.Done = append(week.Done, &types.Entry{
+					Date:   date(2020, time.November, 23+i),
+					Labels: map[string]string{"cat": "primary"},
+				})
+			}
+			totals, err := c.config.GetTotals(types.Log{week})
+			if err != nil {
+				t.Fatalf("wanted no error. got %v", err)
+			}
+			if len(totals) != 3 {
+				t.Fatalf("wanted 3 days. got %v", len(totals))
+			}
+			for _, total := range totals {
+				if total.Absolute != c.want {
+					t.Errorf("wanted %v on %v. got %v", c.want, total.Date.Format("Mon"), total.Absolute)
+				}
+			}
+		})
+	}
+}
+
+func TestUnknownPeriod(t *testing.T) {
+	period := Period("Montly")
+	bc := &BudgetConfig{AggregationPeriod: &period}
+	_, err := bc.GetTotals(types.Log{{Date: date(2020, time.November, 23)}})
+	want := `unknown period "Montly": want Daily, Weekly, Monthly, Quarterly or Yearly`
+	if err == nil || err.Error() != want {
+		t.Errorf("wanted error %q. got %v", want, err)
+	}
+}
@@ -0,0 +1,121 @@
+package budget
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FocusSegment selects a SubTotal at one level of the label grouping. An
+// empty Label matches any label.
+type FocusSegment struct {
+	Label string
+	Value string
+}
+
+// ParseFocusPath parses a "/" separated path of "label=value" or "value"
+// segments, e.g. "cat=primary/sub=thing-one". A value of "?" selects
+// entries without the label.
+func ParseFocusPath(path string) ([]*FocusSegment, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty focus path")
+	}
+	segments := []*FocusSegment{}
+	for _, part := range strings.Split(path, "/") {
+		segment := &FocusSegment{Value: part}
+		if kv := strings.SplitN(part, "=", 2); len(kv) == 2 {
+			segment.Label, segment.Value = kv[0], kv[1]
+		}
+		if segment.Value == "" && segment.Label == "" {
+			return nil, fmt.Errorf("empty segment in focus path %q", path)
+		}
+		if segment.Value == "?" {
+			segment.Value = ""
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+func (f *FocusSegment) Matches(s *SubTotal) bool {
+	return (f.Label == "" || f.Label == s.Label) && f.Value == s.Value
+}
+
+func (f *FocusSegment) String() string {
+	value := f.Value
+	if value == "" {
+		value = "?"
+	}
+	if f.Label == "" {
+		return value
+	}
+	return fmt.Sprintf("%v=%v", f.Label, value)
+}
+
+// Focus narrows totals to the SubTotals under a focus path. See
+// ParseFocusPath.
+func (ts Totals) Focus(path string) (Totals, error) {
+	segments, err := ParseFocusPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return ts.FocusPath(segments), nil
+}
+
+// FocusPath narrows totals to the SubTotals under the SubTotal selected by
+// path, one segment per grouping level. Relative values are relative to
+// the selected SubTotal. Periods without a match are empty.
+func (ts Totals) FocusPath(path []*FocusSegment) Totals {
+	focusedTotals := make(Totals, 0)
+	for _, t := range ts {
+		focusedTotal := &Total{
+			Date:      t.Date,
+			Period:    t.Period,
+			SubTotals: make(SubTotals, 0),
+		}
+		if focus := SubTotals(t.SubTotals).find(path); focus != nil {
+			for _, ss := range focus.SubTotals {
+				focusedTotal.Absolute += ss.Absolute
+			}
+			focusedTotal.SubTotals = focus.SubTotals.relativeTo(focus.Relative)
+		}
+		focusedTotals = append(focusedTotals, focusedTotal)
+	}
+	return focusedTotals
+}
+
+// find returns the SubTotal selected by path, or nil.
+func (ss SubTotals) find(path []*FocusSegment) *SubTotal {
+	if len(path) == 0 {
+		return nil
+	}
+	for _, s := range ss {
+		if !path[0].Matches(s) {
+			continue
+		}
+		if len(path) == 1 {
+			return s
+		}
+		return s.SubTotals.find(path[1:])
+	}
+	return nil
+}
+
+func (ss SubTotals) relativeTo(relative float64) SubTotals {
+	out := make(SubTotals, 0, len(ss))
+	for _, s := range ss {
+		fs := &SubTotal{
+			Label:     s.Label,
+			Value:     s.Value,
+			Path:      s.Path,
+			Absolute:  s.Absolute,
+			Count:     s.Count,
+			Variance:  s.Variance,
+			SubTotals: s.SubTotals.relativeTo(relative),
+		}
+		if relative != 0 {
+			fs.Relative = s.Relative / relative
+		}
+		out = append(out, fs)
+	}
+	return out
+}
@@ -0,0 +1,135 @@
+package budget
+
+import (
+	"time"
+)
+
+// Target is a budget for the share of time spent on a label value.
+// Min and Max are ratios of the total time (e.g. 0.3 for 30%). Value can
+// be a path like "primary/thing-one".
+type Target struct {
+	Label  string
+	Value  string
+	Min    *float64
+	Max    *float64
+	Period *Period
+}
+
+// Violation is a target which was not met in a given period.
+type Violation struct {
+	Date     time.Time
+	Period   Period
+	Label    string
+	Value    string
+	Relative float64
+	Target   *Target
+	Variance float64
+}
+
+func (c *BudgetConfig) targets() []*Target {
+	if c == nil {
+		return nil
+	}
+	return c.Targets
+}
+
+func (c *BudgetConfig) targetLabel(t *Target) string {
+	if t.Label == "" {
+		return c.labelGrouping()[0]
+	}
+	return t.Label
+}
+
+func (t *Target) appliesTo(period Period) bool {
+	return t.Period == nil || *t.Period == period
+}
+
+// variance returns how far relative is outside of the target. It is
+// positive when over Max, negative when under Min and zero otherwise.
+func (t *Target) variance(relative float64) float64 {
+	if t.Max != nil && relative > *t.Max {
+		return relative - *t.Max
+	}
+	if t.Min != nil && relative < *t.Min {
+		return relative - *t.Min
+	}
+	return 0
+}
+
+// applyTargets records the variance from target on each matching
+// SubTotal.
+func (c *BudgetConfig) applyTargets(totals Totals) {
+	for _, total := range totals {
+		for _, target := range c.targets() {
+			if !target.appliesTo(total.Period) {
+				continue
+			}
+			label := c.targetLabel(target)
+			walkSubTotals(total.SubTotals, func(s *SubTotal) bool {
+				if s.Label == label && s.Path == target.Value {
+					s.Variance = target.variance(s.Relative)
+					return true
+				}
+				return false
+			})
+		}
+	}
+}
+
+// GetViolations lists every target which was not met by totals. A target
+// value with no entries in a period counts as 0% of that period.
+func (c *BudgetConfig) GetViolations(totals Totals) []*Violation {
+	violations := []*Violation{}
+	for _, total := range totals {
+		for _, target := range c.targets() {
+			if !target.appliesTo(total.Period) {
+				continue
+			}
+			label := c.targetLabel(target)
+			var relative float64
+			walkSubTotals(total.SubTotals, func(s *SubTotal) bool {
+				if s.Label == label && s.Path == target.Value {
+					relative += s.Relative
+					return true
+				}
+				return false
+			})
+			variance := target.variance(relative)
+			if variance == 0 {
+				continue
+			}
+			violations = append(violations, &Violation{
+				Date:     total.Date,
+				Period:   total.Period,
+				Label:    label,
+				Value:    target.Value,
+				Relative: relative,
+				Target:   target,
+				Variance: variance,
+			})
+		}
+	}
+	return violations
+}
+
+// walkSubTotals calls fn on each SubTotal at any depth. It doesn't walk
+// below a SubTotal for which fn returns true, so that a target on a path
+// like "primary" isn't matched again by the segments nested under it.
+func walkSubTotals(ss []*SubTotal, fn func(*SubTotal) bool) {
+	for _, s := range ss {
+		if !fn(s) {
+			walkSubTotals(s.SubTotals, fn)
+		}
+	}
+}
+
+// GetTarget returns the first target for a value of the top-level
+// grouping label which applies to period, or nil if there is none.
+func (c *BudgetConfig) GetTarget(value string, period Period) *Target {
+	for _, target := range c.targets() {
+		if c.targetLabel(target) == c.labelGrouping()[0] && target.Value == value && target.appliesTo(period) {
+			return target
+		}
+	}
+	return nil
+}
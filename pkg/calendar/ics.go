@@ -0,0 +1,172 @@
+package calendar
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	icsDateFormat = "20060102"
+	// maxRecurrenceYears bounds the expansion of recurrence rules.
+	maxRecurrenceYears = 100
+)
+
+// ReadICS reads every day covered by an event in an iCalendar file.
+func ReadICS(filename string) ([]time.Time, error) {
+	bs, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	days, err := ParseICS(string(bs))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", filename, err)
+	}
+	return days, nil
+}
+
+// ParseICS returns every day covered by an all-day event in an iCalendar
+// document, such as exported public holidays. DTEND is exclusive and
+// defaults to the day after DTSTART. Events with a time of day are
+// skipped, since they don't take a whole day off. Of recurrence rules only
+// yearly ones are expanded ("RRULE:FREQ=YEARLY" with INTERVAL, COUNT or
+// UNTIL), for up to 100 years when unbounded. Other rules are an error and
+// EXDATE is not supported.
+func ParseICS(doc string) ([]time.Time, error) {
+	days := []time.Time{}
+	var start, end time.Time
+	var rule string
+	inEvent, allDay := false, true
+	for _, line := range unfold(doc) {
+		name, value := splitProperty(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent, allDay = true, true
+			start, end, rule = time.Time{}, time.Time{}, ""
+		case name == "END" && value == "VEVENT":
+			inEvent = false
+			if start.IsZero() {
+				return nil, fmt.Errorf("event without DTSTART")
+			}
+			if !allDay {
+				continue
+			}
+			if end.IsZero() || !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			starts, err := occurrences(start, rule)
+			if err != nil {
+				return nil, err
+			}
+			length := int(end.Sub(start).Hours() / 24)
+			for _, s := range starts {
+				for i := 0; i < length; i++ {
+					days = append(days, s.AddDate(0, 0, i))
+				}
+			}
+		case inEvent && (name == "DTSTART" || name == "DTEND"):
+			if len(value) < len(icsDateFormat) {
+				return nil, fmt.Errorf("malformed %v: %q", name, value)
+			}
+			t, err := time.Parse(icsDateFormat, value[:len(icsDateFormat)])
+			if err != nil {
+				return nil, fmt.Errorf("malformed %v: %q", name, value)
+			}
+			if name == "DTSTART" {
+				start = t
+				// A date-time like 20201225T090000Z is a timed event.
+				allDay = len(value) == len(icsDateFormat)
+			} else {
+				end = t
+			}
+		case inEvent && name == "RRULE":
+			rule = value
+		}
+	}
+	return days, nil
+}
+
+// occurrences returns the start of each occurrence of an event starting
+// on start with the recurrence rule, which may be empty. Yearly
+// occurrences on a day missing from a year, like February 29, are
+// skipped.
+func occurrences(start time.Time, rule string) ([]time.Time, error) {
+	if rule == "" {
+		return []time.Time{start}, nil
+	}
+	unsupported := fmt.Errorf("unsupported RRULE %q: want FREQ=YEARLY with INTERVAL, COUNT or UNTIL", rule)
+	interval, count, until := 1, 0, start.AddDate(maxRecurrenceYears, 0, 0)
+	yearly := false
+	for _, part := range strings.Split(rule, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, unsupported
+		}
+		var err error
+		switch strings.ToUpper(kv[0]) {
+		case "FREQ":
+			yearly = strings.ToUpper(kv[1]) == "YEARLY"
+		case "INTERVAL":
+			interval, err = strconv.Atoi(kv[1])
+			if interval < 1 {
+				return nil, unsupported
+			}
+		case "COUNT":
+			count, err = strconv.Atoi(kv[1])
+		case "UNTIL":
+			if len(kv[1]) < len(icsDateFormat) {
+				return nil, unsupported
+			}
+			until, err = time.Parse(icsDateFormat, kv[1][:len(icsDateFormat)])
+		default:
+			return nil, unsupported
+		}
+		if err != nil {
+			return nil, unsupported
+		}
+	}
+	if !yearly {
+		return nil, unsupported
+	}
+	starts := []time.Time{}
+	for year := 0; year <= maxRecurrenceYears; year += interval {
+		t := time.Date(start.Year()+year, start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+		if t.After(until) || (count > 0 && len(starts) == count) {
+			break
+		}
+		if t.Month() != start.Month() {
+			continue
+		}
+		starts = append(starts, t)
+	}
+	return starts, nil
+}
+
+// unfold joins continuation lines, which start with a space or tab.
+func unfold(doc string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(strings.ReplaceAll(doc, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitProperty returns the name (without parameters) and value of a
+// content line like "DTSTART;VALUE=DATE:20201225".
+func splitProperty(line string) (name, value string) {
+	i := strings.Index(line, ":")
+	if i == -1 {
+		return strings.TrimSpace(line), ""
+	}
+	name = line[:i]
+	if j := strings.Index(name, ";"); j != -1 {
+		name = name[:j]
+	}
+	return strings.ToUpper(strings.TrimSpace(name)), strings.TrimSpace(line[i+1:])
+}
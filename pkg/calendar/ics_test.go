@@ -0,0 +1,100 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseICS(t *testing.T) {
+	event := func(lines ...string) string {
+		return "BEGIN:VEVENT\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VEVENT\r\n"
+	}
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		name    string
+		events  []string
+		want    []time.Time
+		wantErr string
+	}{{
+		name: "all-day events",
+		events: []string{
+			event("SUMMARY:Christmas", "DTSTART;VALUE=DATE:20201225", "DTEND;VALUE=DATE:20201227"),
+			event("SUMMARY:New Year's", "  Day", "DTSTART;VALUE=DATE:20210101"),
+		},
+		want: []time.Time{date(2020, time.December, 25), date(2020, time.December, 26), date(2021, time.January, 1)},
+	}, {
+		name: "timed events are skipped",
+		events: []string{
+			event("SUMMARY:Dentist", "DTSTART:20201223T090000Z", "DTEND:20201223T100000Z"),
+			event("SUMMARY:Standup", "DTSTART:20201221T093000", "RRULE:FREQ=WEEKLY"),
+		},
+		want: []time.Time{},
+	}, {
+		name: "yearly with count",
+		events: []string{
+			event("DTSTART;VALUE=DATE:20201225", "DTEND;VALUE=DATE:20201227", "RRULE:FREQ=YEARLY;COUNT=2"),
+		},
+		want: []time.Time{
+			date(2020, time.December, 25), date(2020, time.December, 26),
+			date(2021, time.December, 25), date(2021, time.December, 26),
+		},
+	}, {
+		name: "yearly until with interval",
+		events: []string{
+			event("DTSTART;VALUE=DATE:20200704", "RRULE:FREQ=YEARLY;INTERVAL=2;UNTIL=20250101T000000Z"),
+		},
+		want: []time.Time{date(2020, time.July, 4), date(2022, time.July, 4), date(2024, time.July, 4)},
+	}, {
+		name: "yearly skips missing days",
+		events: []string{
+			event("DTSTART;VALUE=DATE:20200229", "RRULE:FREQ=YEARLY;UNTIL=20241231"),
+		},
+		want: []time.Time{date(2020, time.February, 29), date(2024, time.February, 29)},
+	}, {
+		name: "unsupported rule",
+		events: []string{
+			event("DTSTART;VALUE=DATE:20201126", "RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=4TH"),
+		},
+		wantErr: `unsupported RRULE "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH": want FREQ=YEARLY with INTERVAL, COUNT or UNTIL`,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			doc := "BEGIN:VCALENDAR\r\n" + strings.Join(c.events, "") + "END:VCALENDAR\r\n"
+			days, err := ParseICS(doc)
+			if c.wantErr != "" {
+				if err == nil || err.Error() != c.wantErr {
+					t.Fatalf("wanted error %q. got %v", c.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("wanted no error. got %v", err)
+			}
+			if len(days) != len(c.want) {
+				t.Fatalf("wanted %v. got %v", c.want, days)
+			}
+			for i := range days {
+				if !days[i].Equal(c.want[i]) {
+					t.Errorf("wanted %v. got %v", c.want[i], days[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseICSUnbounded(t *testing.T) {
+	days, err := ParseICS("BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20201225\r\nRRULE:FREQ=YEARLY\r\nEND:VEVENT\r\n")
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if len(days) != maxRecurrenceYears+1 {
+		t.Fatalf("wanted %v days. got %v", maxRecurrenceYears+1, len(days))
+	}
+	if last := days[len(days)-1]; last.Year() != 2020+maxRecurrenceYears {
+		t.Errorf("wanted the last day in %v. got %v", 2020+maxRecurrenceYears, last)
+	}
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var addToOrg string
+
+var CmdAdd = &cobra.Command{
+	Use:   "add <entry>",
+	Short: "Add an entry to this week's log record.",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := getConfig()
+		if err != nil {
+			return err
+		}
+		line := strings.Join(args, " ")
+		entry, _, err := cfg.FileConfig.ParseEntry(line)
+		if err != nil {
+			return err
+		}
+		err = cfg.BudgetConfig.ValidateEntry(entry)
+		if err != nil {
+			return err
+		}
+		if addToOrg != "" {
+			return cfg.FileConfig.AddOrgEntry(addToOrg, line, time.Now())
+		}
+		return cfg.FileConfig.AddEntry(line, time.Now())
+	},
+}
+
+func init() {
+	CmdAdd.Flags().StringVar(&addToOrg, "to-org", "", "Append the entry to an org file as a headline instead.")
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var CmdBudget = &cobra.Command{
+	Use:   "budget",
+	Short: "List budget target violations.",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := getConfig()
+		if err != nil {
+			return err
+		}
+		log, err := cfg.readLog()
+		if err != nil {
+			return err
+		}
+		tots, err := cfg.BudgetConfig.GetTotals(log)
+		if err != nil {
+			return err
+		}
+		sort.Slice(tots, func(i, j int) bool { return tots[i].Date.Before(tots[j].Date) })
+		violations := cfg.BudgetConfig.GetViolations(tots)
+		s, err := cfg.ViewConfig.SprintViolations(violations)
+		if err != nil {
+			return err
+		}
+		fmt.Print(s)
+		return nil
+	},
+}
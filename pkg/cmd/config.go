@@ -5,10 +5,16 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/josephburnett/time-flies/pkg/budget"
 	"github.com/josephburnett/time-flies/pkg/file"
+	"github.com/josephburnett/time-flies/pkg/filter"
+	"github.com/josephburnett/time-flies/pkg/lint"
+	"github.com/josephburnett/time-flies/pkg/rules"
+	"github.com/josephburnett/time-flies/pkg/suggest"
 	"github.com/josephburnett/time-flies/pkg/tidy"
+	"github.com/josephburnett/time-flies/pkg/types"
 	"github.com/josephburnett/time-flies/pkg/view"
 	flag "github.com/spf13/pflag"
 )
@@ -16,18 +22,27 @@ import (
 type Config struct {
 	budget.BudgetConfig
 	file.FileConfig
+	filter.FilterConfig
+	lint.LintConfig
+	rules.RulesConfig
+	suggest.SuggestConfig
 	tidy.TidyConfig
 	view.ViewConfig
 }
 
 var (
 	config = flag.StringP("config", "c", "", "Config file. JSON serialization of pkg/cmd/Config.")
-	focus  = flag.StringP("focus", "f", "", "Focus on a particular label group.")
+	focus  = flag.StringP("focus", "f", "", "Focus on a label group path, e.g. cat=primary/sub=thing-one.")
 	group  = flag.StringSliceP("group", "g", []string{}, "Group entries by labels.")
 	log    = flag.StringP("log", "l", "", "Log file.")
 	org    = flag.StringSliceP("org", "r", []string{}, "Org mode file.")
-	output = flag.StringP("output", "o", "", "Output format.")
-	period = flag.StringP("period", "p", "", "Aggregation period.")
+	md     = flag.StringSliceP("markdown", "m", []string{}, "Markdown file.")
+	output = flag.StringP("output", "o", "", "Output format: Line, Num, JSON or CSV.")
+	period = flag.StringP("period", "p", "", "Aggregation period: Daily, Weekly, Monthly, Quarterly or Yearly.")
+	source = flag.StringSliceP("source", "s", []string{}, "Log source, e.g. notes.org or md:notes.txt.")
+	since  = flag.String("since", "", "Only weeks on or after this date (2006-01-02, 30d or 8w).")
+	until  = flag.String("until", "", "Only weeks on or before this date (2006-01-02, 30d or 8w).")
+	where  = flag.StringSliceP("where", "w", []string{}, "Only entries matching label predicates (k=v, k!=v, k, !k).")
 )
 
 const (
@@ -68,6 +83,9 @@ func getConfig() (*Config, error) {
 	if len(*org) > 0 {
 		cfg.FileConfig.OrgFiles = *org
 	}
+	if len(*md) > 0 {
+		cfg.FileConfig.MarkdownFiles = *md
+	}
 	if *output != "" {
 		cfg.ViewConfig.OutputFormat = output
 	}
@@ -75,5 +93,36 @@ func getConfig() (*Config, error) {
 		budgetPeriod := budget.Period(*period)
 		cfg.BudgetConfig.AggregationPeriod = &budgetPeriod
 	}
+	if len(*source) > 0 {
+		cfg.FileConfig.Sources = []*file.SourceConfig{}
+		for _, path := range *source {
+			cfg.FileConfig.Sources = append(cfg.FileConfig.Sources, &file.SourceConfig{Path: path})
+		}
+	}
+	if *since != "" {
+		cfg.FilterConfig.Since = since
+	}
+	if *until != "" {
+		cfg.FilterConfig.Until = until
+	}
+	if len(*where) > 0 {
+		cfg.FilterConfig.Where = *where
+	}
+	if len(cfg.TidyConfig.HeaderOrder) == 0 {
+		cfg.TidyConfig.HeaderOrder = cfg.FileConfig.HeaderKeys()
+	}
 	return cfg, nil
 }
+
+// readLog reads all log sources, fills in derived labels and applies the
+// filter.
+func (cfg *Config) readLog() (types.Log, error) {
+	log, err := cfg.FileConfig.Read()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.RulesConfig.Apply(log); err != nil {
+		return nil, err
+	}
+	return cfg.FilterConfig.Filter(log, time.Now())
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var CmdDone = &cobra.Command{
+	Use:   "done <match>",
+	Short: "Mark the one TODO entry containing match as done.",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := getConfig()
+		if err != nil {
+			return err
+		}
+		return cfg.FileConfig.CompleteEntry(strings.Join(args, " "), time.Now())
+	},
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/josephburnett/time-flies/pkg/suggest"
+	"github.com/spf13/cobra"
+)
+
+var lintSuggest bool
+
+var CmdLint = &cobra.Command{
+	Use:   "lint",
+	Short: "Check the log file for problems.",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := getConfig()
+		if err != nil {
+			return err
+		}
+		log, errs, err := cfg.FileConfig.ReadLogWithErrors()
+		if err != nil {
+			return err
+		}
+		if err := cfg.RulesConfig.Apply(log); err != nil {
+			return err
+		}
+		var model *suggest.Model
+		if lintSuggest {
+			model = cfg.SuggestConfig.Train(log, cfg.BudgetConfig.GetLabelGrouping())
+		}
+		log, err = cfg.FilterConfig.Filter(log, time.Now())
+		if err != nil {
+			return err
+		}
+		problems := cfg.LintConfig.Lint(log, errs, &cfg.BudgetConfig, &cfg.FileConfig, model)
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		if len(problems) > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("found %v problems", len(problems))
+		}
+		return nil
+	},
+}
+
+func init() {
+	CmdLint.Flags().BoolVar(&lintSuggest, "suggest", false, "Suggest values for missing labels, learned from the log.")
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportHTML string
+)
+
+var CmdReport = &cobra.Command{
+	Use:   "report",
+	Short: "Write a self-contained HTML report.",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := getConfig()
+		if err != nil {
+			return err
+		}
+		log, err := cfg.readLog()
+		if err != nil {
+			return err
+		}
+		tots, err := cfg.BudgetConfig.GetTotals(log)
+		if err != nil {
+			return err
+		}
+		sort.Slice(tots, func(i, j int) bool { return tots[i].Date.Before(tots[j].Date) })
+		s, err := cfg.ViewConfig.SprintHTML(tots, &cfg.BudgetConfig)
+		if err != nil {
+			return err
+		}
+		if reportHTML == "" {
+			fmt.Print(s)
+			return nil
+		}
+		return ioutil.WriteFile(reportHTML, []byte(s), 0644)
+	},
+}
+
+func init() {
+	CmdReport.Flags().StringVar(&reportHTML, "html", "", "HTML file to write. Defaults to stdout.")
+}
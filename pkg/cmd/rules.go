@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var CmdRules = &cobra.Command{
+	Use:   "rules [entry]",
+	Short: "Show which rules set the labels of entries.",
+	Long: "Show which rules set the labels of entries. With an entry, show the\n" +
+		"labels the rules would set on it. Otherwise list every entry in the\n" +
+		"log with derived labels.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := getConfig()
+		if err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			entry, _, err := cfg.FileConfig.ParseEntry(strings.Join(args, " "))
+			if err != nil {
+				return err
+			}
+			if err := cfg.RulesConfig.ApplyEntry(entry); err != nil {
+				return err
+			}
+			fmt.Println(sprintDerived(entry, true))
+			return nil
+		}
+		log, err := cfg.readLog()
+		if err != nil {
+			return err
+		}
+		sort.Slice(log, func(i, j int) bool { return log[i].Date.Before(log[j].Date) })
+		for _, week := range log {
+			for _, entry := range append(append([]*types.Entry{}, week.Done...), week.Todo...) {
+				if len(entry.Derived) > 0 {
+					fmt.Printf("%v: %v\n", entry.Pos, sprintDerived(entry, false))
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// sprintDerived prints an entry's derived labels with the rule which set
+// each, and its other labels too when all is set.
+func sprintDerived(entry *types.Entry, all bool) string {
+	keys := []string{}
+	for k := range entry.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := entry.Line + " ##"
+	for _, k := range keys {
+		rule, derived := entry.Derived[k]
+		switch {
+		case derived:
+			out += fmt.Sprintf(" %v (%v)", types.FormatLabel(k, entry.Labels[k]), rule)
+		case all:
+			out += " " + types.FormatLabel(k, entry.Labels[k])
+		}
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	suggestionsShown = 3
+)
+
+var CmdSuggest = &cobra.Command{
+	Use:   "suggest <line>",
+	Short: "Suggest labels for a line, learned from the log.",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := getConfig()
+		if err != nil {
+			return err
+		}
+		log, err := cfg.readLog()
+		if err != nil {
+			return err
+		}
+		model := cfg.SuggestConfig.Train(log, cfg.BudgetConfig.GetLabelGrouping())
+		line := strings.Join(args, " ")
+		for _, k := range model.Keys() {
+			suggestions := model.Suggest(line, k)
+			if len(suggestions) > suggestionsShown {
+				suggestions = suggestions[:suggestionsShown]
+			}
+			for _, s := range suggestions {
+				fmt.Printf("%v=%v %.0f%%\n", s.Label, s.Value, s.Confidence*100)
+			}
+		}
+		return nil
+	},
+}
@@ -2,10 +2,21 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 
+	"github.com/josephburnett/time-flies/pkg/file"
+	"github.com/josephburnett/time-flies/pkg/tidy"
 	"github.com/spf13/cobra"
 )
 
+var (
+	tidyWrite    bool
+	tidyCheck    bool
+	tidyMarkdown bool
+	tidyCanon    bool
+)
+
 var CmdTidy = &cobra.Command{
 	Use:   "tidy",
 	Short: "Reformats log to spark joy.",
@@ -15,10 +26,41 @@ var CmdTidy = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		log, err := cfg.FileConfig.Read()
+		if tidyWrite || tidyCheck {
+			if cfg.FilterConfig.IsSet() {
+				return fmt.Errorf("filters can't be used with --write or --check")
+			}
+			// Record jars are tidied as documents. Other sources which
+			// can be tidied in place tidy themselves.
+			for _, sc := range cfg.FileConfig.GetSources() {
+				if filename, ok := sc.LogPath(); ok {
+					if err := tidyLogFile(cmd, cfg, filename); err != nil {
+						return err
+					}
+					continue
+				}
+				s, err := cfg.FileConfig.NewSource(sc)
+				if err != nil {
+					return err
+				}
+				if t, ok := s.(file.Tidier); ok {
+					if err := tidyFile(cmd, t.Filename(), func(doc string) string {
+						return t.Tidy(doc, tidyCanon)
+					}); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+		log, err := cfg.readLog()
 		if err != nil {
 			return err
 		}
+		if tidyMarkdown {
+			fmt.Print(cfg.FileConfig.SprintMarkdown(log))
+			return nil
+		}
 		s, err := cfg.TidyConfig.SprintLog(log)
 		if err != nil {
 			return err
@@ -27,3 +69,80 @@ var CmdTidy = &cobra.Command{
 		return nil
 	},
 }
+
+func init() {
+	CmdTidy.Flags().BoolVar(&tidyWrite, "write", false, "Replace the log file with the tidy log, keeping a backup.")
+	CmdTidy.Flags().BoolVar(&tidyCheck, "check", false, "Print a diff and fail if the log file is not tidy.")
+	CmdTidy.Flags().BoolVar(&tidyCanon, "canonical", false, "Rewrite label aliases to their canonical values.")
+	CmdTidy.Flags().BoolVar(&tidyMarkdown, "to-markdown", false, "Print the log as Markdown.")
+}
+
+// tidyLogFile checks or rewrites one record jar alone. Other sources are
+// not merged in since they can't be written back into it.
+func tidyLogFile(cmd *cobra.Command, cfg *Config, filename string) error {
+	before, s, err := tidyLog(cfg, filename)
+	if err != nil {
+		return err
+	}
+	if tidyCheck {
+		diff := tidy.Diff(filename, before, s)
+		if diff == "" {
+			return nil
+		}
+		fmt.Print(diff)
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%v is not tidy", filename)
+	}
+	if s == before {
+		return nil
+	}
+	return file.WriteFile(filename, s)
+}
+
+// tidyLog returns a record jar before and after tidying. Everything tidy
+// doesn't normalize is kept as written. A missing log file is empty.
+func tidyLog(cfg *Config, filename string) (string, string, error) {
+	bs, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) && filename == cfg.FileConfig.GetLogFile() {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	// Tidy doesn't touch a log with syntax errors.
+	if _, err := cfg.FileConfig.ParseLog(string(bs)); err != nil {
+		return "", "", err
+	}
+	doc := cfg.FileConfig.ParseDocument(string(bs))
+	if tidyCanon {
+		cfg.FileConfig.CanonicalizeDocument(doc)
+	}
+	s, err := cfg.TidyConfig.SprintDocument(doc)
+	if err != nil {
+		return "", "", err
+	}
+	return string(bs), s, nil
+}
+
+// tidyFile checks or rewrites a file with its own tidy function, for
+// sources such as org and Markdown files which are edited in place.
+func tidyFile(cmd *cobra.Command, filename string, tidyFn func(string) string) error {
+	bs, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	s := tidyFn(string(bs))
+	if tidyCheck {
+		diff := tidy.Diff(filename, string(bs), s)
+		if diff == "" {
+			return nil
+		}
+		fmt.Print(diff)
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%v is not tidy", filename)
+	}
+	if s == string(bs) {
+		return nil
+	}
+	return file.WriteFile(filename, s)
+}
@@ -16,7 +16,7 @@ var CmdTodo = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		log, err := cfg.FileConfig.Read()
+		log, err := cfg.readLog()
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	carryHeaders []string
+	carryCounter bool
+	moveTodos    bool
+)
+
+var CmdWeek = &cobra.Command{
+	Use:   "week",
+	Short: "Manage week records.",
+}
+
+var CmdWeekNew = &cobra.Command{
+	Use:   "new",
+	Short: "Start a new week, carrying over unfinished TODO entries.",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := getConfig()
+		if err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("header") {
+			cfg.FileConfig.CarryHeaders = carryHeaders
+		}
+		if cmd.Flags().Changed("carried") {
+			cfg.FileConfig.CarryCounter = &carryCounter
+		}
+		if cmd.Flags().Changed("move") {
+			cfg.FileConfig.MoveTodos = &moveTodos
+		}
+		log, err := cfg.FileConfig.ReadLogIfExists()
+		if err != nil {
+			return err
+		}
+		week, err := cfg.FileConfig.NewWeek(log, time.Now())
+		if err != nil {
+			return err
+		}
+		record, err := cfg.TidyConfig.SprintWeek(week)
+		if err != nil {
+			return err
+		}
+		return cfg.FileConfig.InsertWeek(record)
+	},
+}
+
+func init() {
+	CmdWeekNew.Flags().StringSliceVar(&carryHeaders, "header", nil, "Header fields to keep from the latest week.")
+	CmdWeekNew.Flags().BoolVar(&carryCounter, "carried", false, "Count how many weeks each TODO has been carried over.")
+	CmdWeekNew.Flags().BoolVar(&moveTodos, "move", false, "Remove carried over TODOs from the latest week.")
+	CmdWeek.AddCommand(CmdWeekNew)
+}
@@ -0,0 +1,108 @@
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// AddEntry appends line to the log record for the week containing now,
+// creating the record if there isn't one. The line is validated before
+// anything is written.
+func (c *FileConfig) AddEntry(line string, now time.Time) error {
+	line = c.dewhite(line)
+	if line == "" {
+		return fmt.Errorf("empty entry")
+	}
+	if _, _, err := c.ParseEntry(line); err != nil {
+		return err
+	}
+	filename := c.GetLogFile()
+	bs, err := ioutil.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	recordJar, err := c.appendEntry(string(bs), line, now)
+	if err != nil {
+		return err
+	}
+	return c.WriteLog(recordJar)
+}
+
+func (c *FileConfig) appendEntry(recordJar, line string, now time.Time) (string, error) {
+	week := c.WeekOf(now)
+	if strings.TrimSpace(recordJar) == "" {
+		return newRecord(week, line), nil
+	}
+	records := strings.Split(recordJar, "%%\n")
+	for i, record := range records {
+		w, err := c.ParseWeek(record)
+		if err != nil {
+			return "", err
+		}
+		if c.WeekOf(w.Date).Equal(week) {
+			records[i] = appendLine(record, line)
+			return strings.Join(records, "%%\n"), nil
+		}
+	}
+	return c.insertRecord(recordJar, newRecord(week, line))
+}
+
+// insertRecord adds record to recordJar, keeping it in the same order
+// (newest first or oldest first) as the existing records.
+func (c *FileConfig) insertRecord(recordJar, record string) (string, error) {
+	if strings.TrimSpace(recordJar) == "" {
+		return record, nil
+	}
+	log, err := c.ParseLog(recordJar)
+	if err != nil {
+		return "", err
+	}
+	if log[len(log)-1].Date.After(log[0].Date) {
+		// Oldest first. New weeks go at the end.
+		if !strings.HasSuffix(recordJar, "\n") {
+			recordJar += "\n"
+		}
+		return recordJar + "%%\n" + record, nil
+	}
+	return record + "%%\n" + recordJar, nil
+}
+
+func newRecord(week time.Time, line string) string {
+	return fmt.Sprintf("Date: %v\n\n%v\n\n", week.Format(dateFormat), line)
+}
+
+// appendLine adds line after the last entry in the body of record.
+func appendLine(record, line string) string {
+	lines := strings.Split(record, "\n")
+	headerEnd := -1
+	lastEntry := -1
+	for i, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			if headerEnd == -1 {
+				headerEnd = i
+			}
+			continue
+		}
+		if headerEnd != -1 {
+			lastEntry = i
+		}
+	}
+	switch {
+	case headerEnd == -1:
+		// Header only without a trailing newline.
+		return record + "\n\n" + line + "\n"
+	case lastEntry == -1:
+		lastEntry = headerEnd
+	}
+	out := append([]string{}, lines[:lastEntry+1]...)
+	out = append(out, line)
+	out = append(out, lines[lastEntry+1:]...)
+	if lastEntry+1 == len(lines) {
+		// Keep the record newline terminated.
+		out = append(out, "")
+	}
+	return strings.Join(out, "\n")
+}
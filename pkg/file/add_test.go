@@ -0,0 +1,47 @@
+package file
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAppendEntry(t *testing.T) {
+	now := time.Date(2020, time.November, 25, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name      string
+		recordJar string
+		want      string
+	}{{
+		name:      "empty log",
+		recordJar: "",
+		want:      "Date: Nov 23 2020\n\nnew ## cat=a\n\n",
+	}, {
+		name:      "existing week",
+		recordJar: "Date: Nov 23 2020\n\none ## cat=a\n\n%%\nDate: Nov 16 2020\n\ntwo ## cat=b\n",
+		want:      "Date: Nov 23 2020\n\none ## cat=a\nnew ## cat=a\n\n%%\nDate: Nov 16 2020\n\ntwo ## cat=b\n",
+	}, {
+		name:      "existing week without entries",
+		recordJar: "Date: Nov 24 2020\n",
+		want:      "Date: Nov 24 2020\n\nnew ## cat=a\n",
+	}, {
+		name:      "new week newest first",
+		recordJar: "Date: Nov 16 2020\n\ntwo ## cat=b\n",
+		want:      "Date: Nov 23 2020\n\nnew ## cat=a\n\n%%\nDate: Nov 16 2020\n\ntwo ## cat=b\n",
+	}, {
+		name:      "new week oldest first",
+		recordJar: "Date: Nov 9 2020\n\none ## cat=a\n%%\nDate: Nov 16 2020\n\ntwo ## cat=b",
+		want:      "Date: Nov 9 2020\n\none ## cat=a\n%%\nDate: Nov 16 2020\n\ntwo ## cat=b\n%%\nDate: Nov 23 2020\n\nnew ## cat=a\n\n",
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := (*FileConfig)(nil).appendEntry(c.recordJar, "new ## cat=a", now)
+			if err != nil {
+				t.Fatalf("wanted no error. got %v", err)
+			}
+			if got != c.want {
+				t.Errorf("wanted %q. got %q", c.want, got)
+			}
+		})
+	}
+}
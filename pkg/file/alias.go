@@ -0,0 +1,110 @@
+package file
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+func (c *FileConfig) labelAliases(key string) map[string]string {
+	if c == nil {
+		return nil
+	}
+	return c.LabelAliases[key]
+}
+
+func (c *FileConfig) foldsCase(key string) bool {
+	if c == nil {
+		return false
+	}
+	return contains(c.FoldLabelCase, key) || contains(c.FoldLabelCase, "*")
+}
+
+// CanonicalValue returns the canonical spelling of a label value. Aliases
+// are replaced by their canonical value and, for keys which fold case,
+// values are matched ignoring case and lowercased when they have no
+// alias.
+func (c *FileConfig) CanonicalValue(key, value string) string {
+	lookup := value
+	if c.foldsCase(key) {
+		lookup = strings.ToLower(value)
+	}
+	if canonical, ok := c.aliasIndex(key)[lookup]; ok {
+		return canonical
+	}
+	return lookup
+}
+
+// aliasIndex maps the aliases and canonical values of a label key, as
+// looked up by CanonicalValue, to their canonical value. It is built once
+// per key. Aliases are taken in sorted order, so the first one wins when
+// a value is listed twice.
+func (c *FileConfig) aliasIndex(key string) map[string]string {
+	if c == nil {
+		return nil
+	}
+	if index, ok := c.aliasIndexes[key]; ok {
+		return index
+	}
+	aliases := c.labelAliases(key)
+	names := []string{}
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+	index := map[string]string{}
+	add := func(value, canonical string) {
+		if c.foldsCase(key) {
+			value = strings.ToLower(value)
+		}
+		if _, ok := index[value]; !ok {
+			index[value] = canonical
+		}
+	}
+	for _, alias := range names {
+		add(alias, aliases[alias])
+		add(aliases[alias], aliases[alias])
+	}
+	if c.aliasIndexes == nil {
+		c.aliasIndexes = map[string]map[string]string{}
+	}
+	c.aliasIndexes[key] = index
+	return index
+}
+
+// canonicalLabel returns the canonical spelling of each value of a label.
+func (c *FileConfig) canonicalLabel(key, value string) string {
+	values := types.LabelValues(value)
+	for i := range values {
+		values[i] = c.CanonicalValue(key, values[i])
+	}
+	return types.JoinLabelValues(values)
+}
+
+// canonicalize replaces the label values of an entry with their canonical
+// spelling. It reports whether anything changed.
+func (c *FileConfig) canonicalize(entry *types.Entry) bool {
+	changed := false
+	for k, v := range entry.Labels {
+		if canonical := c.canonicalLabel(k, v); canonical != v {
+			entry.Labels[k] = canonical
+			changed = true
+		}
+	}
+	return changed
+}
+
+// CanonicalizeDocument rewrites the labels of every entry in doc to their
+// canonical values and returns the number of entries changed.
+func (c *FileConfig) CanonicalizeDocument(doc *Document) int {
+	count := 0
+	for _, r := range doc.Records {
+		for _, n := range r.Body {
+			if n.Kind == EntryNode && n.Entry != nil && c.canonicalize(n.Entry) {
+				count++
+			}
+		}
+	}
+	return count
+}
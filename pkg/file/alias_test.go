@@ -0,0 +1,111 @@
+package file
+
+import (
+	"testing"
+)
+
+func TestCanonicalValue(t *testing.T) {
+	c := &FileConfig{
+		LabelAliases: map[string]map[string]string{
+			"cat": {"biz": "business-stuff", "business": "business-stuff"},
+			"sub": {"operations": "Ops"},
+		},
+		FoldLabelCase: []string{"sub"},
+	}
+	cases := []struct {
+		key   string
+		value string
+		want  string
+	}{
+		{"cat", "biz", "business-stuff"},
+		{"cat", "business", "business-stuff"},
+		{"cat", "Business", "Business"},
+		{"cat", "business-stuff", "business-stuff"},
+		{"sub", "OPERATIONS", "Ops"},
+		{"sub", "ops", "Ops"},
+		{"sub", "CI", "ci"},
+		{"other", "Biz", "Biz"},
+	}
+	for _, tc := range cases {
+		if got := c.CanonicalValue(tc.key, tc.value); got != tc.want {
+			t.Errorf("%v=%v: wanted %q. got %q", tc.key, tc.value, tc.want, got)
+		}
+	}
+}
+
+func TestCanonicalEntries(t *testing.T) {
+	c := &FileConfig{
+		LabelAliases: map[string]map[string]string{
+			"cat": {"biz": "business-stuff"},
+		},
+	}
+	record := "Date: Nov 23 2020\n\nthing ## cat=biz\n"
+	week, err := c.ParseWeek(record)
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if got := week.Done[0].Labels["cat"]; got != "business-stuff" {
+		t.Errorf("wanted parsed entries to be canonical. got cat=%v", got)
+	}
+	org, err := c.ParseOrg("* DONE thing :cat@biz:\n")
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if got := org[0].Done[0].Labels["cat"]; got != "business-stuff" {
+		t.Errorf("wanted org entries to be canonical. got cat=%v", got)
+	}
+	doc := c.ParseDocument(record)
+	entry := doc.Records[0].Body[1].Entry
+	if got := entry.Labels["cat"]; got != "biz" {
+		t.Errorf("wanted the document to keep labels as written. got cat=%v", got)
+	}
+	if n := c.CanonicalizeDocument(doc); n != 1 {
+		t.Errorf("wanted 1 entry canonicalized. got %v", n)
+	}
+}
+
+func TestTidyCanonical(t *testing.T) {
+	c := &FileConfig{
+		LabelAliases: map[string]map[string]string{
+			"cat": {"biz": "business-stuff"},
+		},
+	}
+	markdown := "## Week of Nov 23 2020\n\n- [x] a  <!-- cat=biz -->\n"
+	org := "* DONE a :cat@biz:\n"
+	cases := []struct {
+		name      string
+		tidy      func(string, bool) string
+		doc       string
+		canonical bool
+		want      string
+	}{{
+		name: "markdown as written",
+		tidy: c.TidyMarkdown,
+		doc:  markdown,
+		want: markdown,
+	}, {
+		name:      "markdown canonical",
+		tidy:      c.TidyMarkdown,
+		doc:       markdown,
+		canonical: true,
+		want:      "## Week of Nov 23 2020\n\n- [x] a  <!-- cat=business-stuff -->\n",
+	}, {
+		name: "org as written",
+		tidy: c.TidyOrg,
+		doc:  org,
+		want: org,
+	}, {
+		name:      "org canonical",
+		tidy:      c.TidyOrg,
+		doc:       org,
+		canonical: true,
+		want:      "* DONE a :cat@business_stuff:\n",
+	}}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.tidy(tc.doc, tc.canonical); got != tc.want {
+				t.Errorf("wanted %q. got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// CompleteEntry marks the one TODO entry whose line contains match as
+// done, in the log file or in one of the org files.
+func (c *FileConfig) CompleteEntry(match string, now time.Time) error {
+	type edit struct {
+		filename string
+		content  string
+	}
+	edits := []*edit{}
+	count := 0
+	// A log file is optional when entries are kept in other sources.
+	bs, err := ioutil.ReadFile(c.GetLogFile())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		recordJar, n, err := c.completeLog(string(bs), match)
+		if err != nil {
+			return err
+		}
+		count += n
+		if n == 1 {
+			edits = append(edits, &edit{c.GetLogFile(), recordJar})
+		}
+	}
+	for _, f := range c.GetOrgFiles() {
+		bs, err := ioutil.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		doc, n := completeOrg(string(bs), match, now)
+		count += n
+		if n == 1 {
+			edits = append(edits, &edit{f, doc})
+		}
+	}
+	switch {
+	case count == 0:
+		return fmt.Errorf("no TODO entry matches %q", match)
+	case count > 1:
+		return fmt.Errorf("%v TODO entries match %q", count, match)
+	}
+	return writeFile(edits[0].filename, []byte(edits[0].content))
+}
+
+// completeLog marks the TODO entry whose line contains match as done. It
+// returns the number of matching TODO entries; the record jar is only
+// changed when there is exactly one.
+func (c *FileConfig) completeLog(recordJar, match string) (string, int, error) {
+	lines := strings.Split(recordJar, "\n")
+	found := -1
+	count := 0
+	inBody := false
+	for i, raw := range lines {
+		line := c.dewhite(raw)
+		switch {
+		case line == "%%":
+			inBody = false
+			continue
+		case !inBody:
+			inBody = line == ""
+			continue
+		case line == "":
+			continue
+		}
+		if _, ok := ParseDayMarker(line); ok || c.isComment(line) {
+			continue
+		}
+		entry, done, err := c.ParseEntry(line)
+		if err != nil {
+			return "", 0, err
+		}
+		if !done && strings.Contains(entry.Line, match) {
+			found = i
+			count++
+		}
+	}
+	if count != 1 {
+		return recordJar, count, nil
+	}
+	raw := lines[found]
+	trimmed := strings.TrimLeft(raw, " \t")
+	indent := raw[:len(raw)-len(trimmed)]
+	if strings.HasPrefix(trimmed, "[ ]") {
+		trimmed = strings.TrimSpace(trimmed[3:])
+	} else {
+		trimmed = strings.TrimSpace(trimmed[1:])
+	}
+	lines[found] = indent + "[x] " + trimmed
+	return strings.Join(lines, "\n"), 1, nil
+}
@@ -0,0 +1,41 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+// ParseError is a syntax error at a position in a log file.
+type ParseError struct {
+	Pos types.Position
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%v: record %v: %v", e.Pos, e.Pos.Record, e.Msg)
+}
+
+// ParseErrors are all the syntax errors found in a log file.
+type ParseErrors []*ParseError
+
+func (es ParseErrors) Error() string {
+	msgs := []string{}
+	for _, e := range es {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+type labelError struct {
+	pair   string
+	reason string
+}
+
+func (e *labelError) Error() string {
+	if e.reason != "" {
+		return fmt.Sprintf("malformed 'k=v' labels: %q: %v", e.pair, e.reason)
+	}
+	return fmt.Sprintf("malformed 'k=v' labels: %q", e.pair)
+}
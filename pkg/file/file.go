@@ -1,24 +1,48 @@
 package file
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/mail"
+	"net/textproto"
 	"os"
 	"regexp"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/josephburnett/time-flies/pkg/types"
 )
 
 const (
-	defaultLogFile = ".tf/log"
+	defaultLogFile   = ".tf/log"
+	defaultWeekStart = time.Monday
+	dateFormat       = "Jan 02 2006"
 )
 
 type FileConfig struct {
-	LogFile  *string
-	OrgFiles []string
+	LogFile       *string
+	OrgFiles      []string
+	MarkdownFiles []string
+	Sources       []*SourceConfig
+	HeaderSchema  []*HeaderField
+	// LabelAliases maps label keys to alias values and their canonical
+	// value, e.g. {"cat": {"biz": "business-stuff"}}.
+	LabelAliases map[string]map[string]string
+	// FoldLabelCase lists the label keys, or "*" for all, whose values
+	// are compared ignoring case and lowercased.
+	FoldLabelCase []string
+	// Comments makes body lines starting with "//" comments rather than
+	// entries.
+	Comments     *bool
+	WeekStart    *string
+	CarryHeaders []string
+	CarryCounter *bool
+	MoveTodos    *bool
+
+	// aliasIndexes caches the alias lookup of each label key.
+	aliasIndexes map[string]map[string]string
 }
 
 func (c *FileConfig) GetLogFile() string {
@@ -29,20 +53,60 @@ func (c *FileConfig) GetLogFile() string {
 	return *c.LogFile
 }
 
+func (c *FileConfig) weekStart() time.Weekday {
+	if c == nil || c.WeekStart == nil {
+		return defaultWeekStart
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), *c.WeekStart) {
+			return d
+		}
+	}
+	return defaultWeekStart
+}
+
+// WeekOf returns the date of the first day of the week containing t.
+func (c *FileConfig) WeekOf(t time.Time) time.Time {
+	year, month, day := t.Date()
+	offset := (int(t.Weekday()) - int(c.weekStart()) + 7) % 7
+	return time.Date(year, month, day-offset, 0, 0, 0, 0, time.UTC)
+}
+
+// Read reads and merges every source of the log.
 func (c *FileConfig) Read() (types.Log, error) {
-	l, err := c.ReadLog()
+	return c.readSources(c.GetSources())
+}
+
+func (c *FileConfig) ReadLog() (types.Log, error) {
+	log, errs, err := c.ReadLogWithErrors()
 	if err != nil {
 		return nil, err
 	}
-	o, err := c.ReadOrg()
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return log, nil
+}
+
+// ReadLogWithErrors reads every week of the log which can be parsed,
+// along with every parse error found.
+func (c *FileConfig) ReadLogWithErrors() (types.Log, ParseErrors, error) {
+	filename := c.GetLogFile()
+	bs, err := ioutil.ReadFile(filename)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return mergeLogs(l, o), nil
+	log, errs := c.parseLog(filename, string(bs))
+	return log, errs, nil
 }
 
-func (c *FileConfig) ReadLog() (types.Log, error) {
+// ReadLogIfExists is like ReadLog but returns an empty log when the log
+// file doesn't exist yet.
+func (c *FileConfig) ReadLogIfExists() (types.Log, error) {
 	bs, err := ioutil.ReadFile(c.GetLogFile())
+	if os.IsNotExist(err) || (err == nil && strings.TrimSpace(string(bs)) == "") {
+		return types.Log{}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -50,50 +114,133 @@ func (c *FileConfig) ReadLog() (types.Log, error) {
 }
 
 func (c *FileConfig) ParseLog(recordJar string) (types.Log, error) {
+	log, errs := c.parseLog("", recordJar)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return log, nil
+}
+
+func (c *FileConfig) parseLog(filename, recordJar string) (types.Log, ParseErrors) {
 	log := []*types.Week{}
-	for _, record := range strings.Split(recordJar, "%%\n") {
-		week, err := c.ParseWeek(record)
-		if err != nil {
-			return nil, err
+	var errs ParseErrors
+	line := 1
+	for i, record := range strings.Split(recordJar, "%%\n") {
+		pos := types.Position{
+			File:   filename,
+			Record: i + 1,
+			Line:   line,
 		}
-		log = append(log, week)
+		week, weekErrs := c.parseWeek(record, pos)
+		errs = append(errs, weekErrs...)
+		if week != nil {
+			log = append(log, week)
+		}
+		line += strings.Count(record, "\n") + 1
 	}
-	return log, nil
+	return log, errs
 }
 
 func (c *FileConfig) ParseWeek(record string) (*types.Week, error) {
+	week, errs := c.parseWeek(record, types.Position{Record: 1, Line: 1})
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return week, nil
+}
+
+// parseWeek parses a record starting at pos. Every error in the record is
+// collected and entries which can't be parsed are left out of the week.
+// The week is nil when its header is invalid.
+func (c *FileConfig) parseWeek(record string, pos types.Position) (*types.Week, ParseErrors) {
+	var errs ParseErrors
+	errorf := func(line, column int, format string, a ...interface{}) {
+		p := pos
+		p.Line += line
+		p.Column = column
+		errs = append(errs, &ParseError{
+			Pos: p,
+			Msg: fmt.Sprintf(format, a...),
+		})
+	}
 	message, err := mail.ReadMessage(strings.NewReader(record))
 	if err != nil {
-		return nil, err
-	}
-	body, _ := ioutil.ReadAll(message.Body)
-	date, ok := message.Header["Date"]
-	if !ok {
-		return nil, fmt.Errorf("missing required 'Date' header:\n%v", record)
+		errorf(0, 0, "%v", err)
+		return nil, errs
 	}
-	if len(date) > 1 {
-		return nil, fmt.Errorf("duplicate 'Date' header:\n%v", record)
+	lines := strings.Split(record, "\n")
+	bodyStart := len(lines)
+	dateLines := []int{}
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			bodyStart = i + 1
+			break
+		}
+		// Header keys are case-insensitive, as in net/mail.
+		if k := strings.SplitN(line, ":", 2); len(k) == 2 && textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(k[0])) == "Date" {
+			dateLines = append(dateLines, i)
+		}
 	}
-	header := message.Header
-	delete(header, "Date")
-	t, err := c.parseDate(date[0])
-	if err != nil {
-		return nil, fmt.Errorf("invalid date 'January 2, 2006' date format: %v", date)
+	// The first and last Date lines, or the start of the record if they
+	// can't be found.
+	firstDate, lastDate := 0, 0
+	if len(dateLines) > 0 {
+		firstDate, lastDate = dateLines[0], dateLines[len(dateLines)-1]
 	}
-	week := &types.Week{
-		Date:   t,
-		Header: message.Header,
-		Done:   []*types.Entry{},
-		Todo:   []*types.Entry{},
+	var week *types.Week
+	date, ok := message.Header["Date"]
+	switch {
+	case !ok:
+		errorf(0, 0, "missing required 'Date' header")
+	case len(date) > 1:
+		errorf(lastDate, 1, "duplicate 'Date' header")
+	default:
+		header := message.Header
+		delete(header, "Date")
+		t, err := c.parseDate(date[0])
+		if err != nil {
+			errorf(firstDate, strings.Index(lines[firstDate], date[0])+1,
+				"invalid date %q: want a date like 'January 2, 2006'", date[0])
+			break
+		}
+		week = &types.Week{
+			Date:   t,
+			Header: message.Header,
+			Done:   []*types.Entry{},
+			Todo:   []*types.Entry{},
+			Pos:    pos,
+		}
 	}
-	for _, line := range strings.Split(string(body), "\n") {
-		line = c.dewhite(line)
-		if line == "" {
+	var day time.Time
+	for i := bodyStart; i < len(lines); i++ {
+		raw := lines[i]
+		line := c.dewhite(raw)
+		if line == "" || c.isComment(line) {
+			continue
+		}
+		if weekday, ok := ParseDayMarker(line); ok {
+			if week != nil {
+				day = DayOf(week.Date, weekday)
+			}
 			continue
 		}
-		entry, done, err := c.ParseEntry(line)
+		column := len(raw) - len(strings.TrimLeft(raw, " \t")) + 1
+		entry, done, err := c.ParseEntry(raw)
 		if err != nil {
-			return nil, err
+			var le *labelError
+			if errors.As(err, &le) {
+				labels := strings.LastIndex(raw, "##")
+				column = labels + strings.Index(raw[labels:], le.pair) + 1
+			}
+			errorf(i, column, "%v", err)
+			continue
+		}
+		entry.Pos = pos
+		entry.Pos.Line += i
+		entry.Pos.Column = column
+		entry.Date = day
+		if week == nil {
+			continue
 		}
 		if done {
 			week.Done = append(week.Done, entry)
@@ -101,7 +248,33 @@ func (c *FileConfig) ParseWeek(record string) (*types.Week, error) {
 			week.Todo = append(week.Todo, entry)
 		}
 	}
-	return week, nil
+	return week, errs
+}
+
+var dayMarker = regexp.MustCompile(`^(?:--- ?([A-Za-z]{3,9}):?|([A-Za-z]{3,9}):)$`)
+
+// ParseDayMarker recognizes a line starting a day section in the body of
+// a week, e.g. "Mon:", "Tuesday:" or "--- Wed".
+func ParseDayMarker(line string) (time.Weekday, bool) {
+	m := dayMarker.FindStringSubmatch(line)
+	if m == nil {
+		return 0, false
+	}
+	name := strings.ToLower(m[1] + m[2])
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if name == full || name == full[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// DayOf returns the first date on or after the start of a week which
+// falls on weekday.
+func DayOf(week time.Time, weekday time.Weekday) time.Time {
+	offset := (int(weekday) - int(week.Weekday()) + 7) % 7
+	return week.AddDate(0, 0, offset)
 }
 
 func (c *FileConfig) parseDate(s string) (time.Time, error) {
@@ -133,41 +306,71 @@ func (c *FileConfig) isDone(line string) (todo bool, rest string) {
 	return true, line
 }
 
+// ParseEntry parses an entry line with its label values in their
+// canonical spelling.
 func (c *FileConfig) ParseEntry(line string) (*types.Entry, bool, error) {
-	done, line := c.isDone(c.dewhite(line))
-	components := strings.Split(line, "##")
-	if len(components) == 1 {
+	entry, done, err := c.parseEntry(line)
+	if err != nil {
+		return nil, false, err
+	}
+	c.canonicalize(entry)
+	return entry, done, nil
+}
+
+// parseEntry parses an entry line with its labels as written. The labels
+// follow the first "##" after which the rest of the line reads as labels,
+// so that quoted values can contain "##". Whitespace is collapsed in the
+// line but not within quotes.
+func (c *FileConfig) parseEntry(line string) (*types.Entry, bool, error) {
+	done, line := c.isDone(strings.TrimSpace(line))
+	cut := strings.Index(line, "##")
+	if cut < 0 {
 		return &types.Entry{
-			Line:   line,
+			Line:   c.dewhite(line),
 			Labels: map[string]string{},
 		}, done, nil
 	}
-	last := components[len(components)-1]
-	cut := len(line) - len(last)
-	labels, err := c.parseLabels(line[cut:])
+	var labels map[string]string
+	var err error
+	for {
+		labels, err = c.parseLabels(line[cut+2:])
+		next := strings.Index(line[cut+1:], "##")
+		if err == nil || next < 0 {
+			break
+		}
+		cut += 1 + next
+	}
 	if err != nil {
 		return nil, false, err
 	}
-	line = line[:cut-2]
-	line = c.dewhite(line)
 	return &types.Entry{
-		Line:   line,
+		Line:   c.dewhite(line[:cut]),
 		Labels: labels,
 	}, done, nil
 }
 
+// parseLabels parses "k=v" labels separated by whitespace. Values can be
+// quoted ("note=\"pairing session\"") and be lists separated by commas
+// ("with=alice,bob"). "\" escapes a following quote, comma, backslash or
+// space.
 func (c *FileConfig) parseLabels(line string) (map[string]string, error) {
-	line = c.dewhite(line)
+	line = strings.TrimSpace(line)
 	labels := map[string]string{}
-	for _, pair := range strings.Split(line, " ") {
-		if pair == "" {
-			continue
+	for line != "" {
+		pair, rest, err := scanLabel(line)
+		if err != nil {
+			return nil, err
 		}
-		kv := strings.Split(pair, "=")
-		if len(kv) != 2 {
-			return nil, fmt.Errorf("malformed 'k=v' labels: %q", pair)
+		line = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		i := strings.Index(pair, "=")
+		if i < 1 {
+			return nil, &labelError{pair: pair}
+		}
+		values, err := parseLabelValues(pair[i+1:])
+		if err != nil {
+			return nil, &labelError{pair: pair, reason: err.Error()}
 		}
-		labels[kv[0]] = kv[1]
+		labels[pair[:i]] = types.JoinLabelValues(values)
 	}
 	return labels, nil
 }
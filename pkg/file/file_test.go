@@ -0,0 +1,205 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+func TestParseLogErrors(t *testing.T) {
+	recordJar := `Date: Nov 23 2020
+
+good thing ## cat=a
+bad thing  ## cat=a broken
+%%
+Date: Bogus
+
+other thing ## cat="b c
+%%
+date: notadate
+
+[x] a
+%%
+date: Nov 23 2020
+date: Nov 30 2020
+
+[x] b
+`
+	log, errs := (*FileConfig)(nil).parseLog("log", recordJar)
+	want := []string{
+		`log:4:21: record 1: malformed 'k=v' labels: "broken"`,
+		`log:6:7: record 2: invalid date "Bogus": want a date like 'January 2, 2006'`,
+		`log:8:16: record 2: malformed 'k=v' labels: "cat=\"b c": unterminated quote`,
+		`log:10:7: record 3: invalid date "notadate": want a date like 'January 2, 2006'`,
+		`log:15:1: record 4: duplicate 'Date' header`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("wanted %v errors. got %v", len(want), errs)
+	}
+	for i, e := range errs {
+		if e.Error() != want[i] {
+			t.Errorf("wanted error %q. got %q", want[i], e.Error())
+		}
+	}
+	if len(log) != 1 || len(log[0].Done) != 1 {
+		t.Fatalf("wanted 1 week with 1 entry. got %v", log)
+	}
+	if got := log[0].Done[0].Pos.Line; got != 3 {
+		t.Errorf("wanted entry on line 3. got %v", got)
+	}
+}
+
+func TestParseDaySections(t *testing.T) {
+	record := `Date: Nov 23 2020
+
+undated ## cat=a
+Mon:
+first ## cat=a
+--- Wed
+third ## cat=b
+Tuesday:
+# second ##
+`
+	week, err := (*FileConfig)(nil).ParseWeek(record)
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	want := map[string]string{
+		"undated": "",
+		"first":   "Nov 23",
+		"third":   "Nov 25",
+		"second":  "Nov 24",
+	}
+	entries := append(week.Done, week.Todo...)
+	if len(entries) != len(want) {
+		t.Fatalf("wanted %v entries. got %v", len(want), len(entries))
+	}
+	for _, e := range entries {
+		got := ""
+		if !e.Date.IsZero() {
+			got = e.Date.Format("Jan 02")
+		}
+		if got != want[e.Line] {
+			t.Errorf("wanted %q on %q. got %q", e.Line, want[e.Line], got)
+		}
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	cases := []struct {
+		labels  string
+		want    map[string]string
+		wantErr bool
+	}{{
+		labels: "cat=a  sub=b",
+		want:   map[string]string{"cat": "a", "sub": "b"},
+	}, {
+		labels: "with=alice,bob",
+		want:   map[string]string{"with": "alice,bob"},
+	}, {
+		labels: `note="pairing session" cat=a`,
+		want:   map[string]string{"note": "pairing session", "cat": "a"},
+	}, {
+		labels: "query=a=b",
+		want:   map[string]string{"query": "a=b"},
+	}, {
+		labels: `note="a, b",c`,
+		want:   map[string]string{"note": `a\, b,c`},
+	}, {
+		labels: `note=say\ \"hi\"`,
+		want:   map[string]string{"note": `say "hi"`},
+	}, {
+		labels: `path=C:\foo`,
+		want:   map[string]string{"path": `C:\foo`},
+	}, {
+		labels:  `note="open`,
+		wantErr: true,
+	}, {
+		labels:  "=a",
+		wantErr: true,
+	}}
+	for _, c := range cases {
+		got, err := (*FileConfig)(nil).parseLabels(c.labels)
+		if err != nil {
+			if !c.wantErr {
+				t.Errorf("%v: wanted no error. got %v", c.labels, err)
+			}
+			continue
+		}
+		if c.wantErr {
+			t.Errorf("%v: wanted error. got %v", c.labels, got)
+			continue
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("%v: wanted %v. got %v", c.labels, c.want, got)
+		}
+		for k, v := range c.want {
+			if got[k] != v {
+				t.Errorf("%v: wanted %v=%q. got %q", c.labels, k, v, got[k])
+			}
+			s := types.FormatLabel(k, got[k])
+			if again, _ := (*FileConfig)(nil).parseLabels(s); again[k] != v {
+				t.Errorf("%v: wanted %q to read back as %q. got %q", c.labels, s, v, again[k])
+			}
+		}
+	}
+}
+
+func TestParseEntryQuotes(t *testing.T) {
+	cases := []struct {
+		line   string
+		want   string
+		labels map[string]string
+	}{{
+		line:   `[x] pair   up ## note="a    b"`,
+		want:   "pair up",
+		labels: map[string]string{"note": "a    b"},
+	}, {
+		line:   `[x] read ## note="see ## 3" cat=a`,
+		want:   "read",
+		labels: map[string]string{"note": "see ## 3", "cat": "a"},
+	}, {
+		line:   "[x] one ## two ## cat=a",
+		want:   "one ## two",
+		labels: map[string]string{"cat": "a"},
+	}}
+	for _, c := range cases {
+		entry, _, err := (*FileConfig)(nil).ParseEntry(c.line)
+		if err != nil {
+			t.Errorf("%v: wanted no error. got %v", c.line, err)
+			continue
+		}
+		if entry.Line != c.want {
+			t.Errorf("%v: wanted line %q. got %q", c.line, c.want, entry.Line)
+		}
+		if len(entry.Labels) != len(c.labels) {
+			t.Errorf("%v: wanted %v. got %v", c.line, c.labels, entry.Labels)
+		}
+		for k, v := range c.labels {
+			if entry.Labels[k] != v {
+				t.Errorf("%v: wanted %v=%q. got %q", c.line, k, v, entry.Labels[k])
+			}
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	record := "Date: Nov 23 2020\n\n// not a comment ## cat=a\nthing ## cat=b\n"
+	yes := true
+	cases := []struct {
+		config *FileConfig
+		want   int
+	}{
+		{nil, 2},
+		{&FileConfig{Comments: &yes}, 1},
+	}
+	for _, c := range cases {
+		week, err := c.config.ParseWeek(record)
+		if err != nil {
+			t.Fatalf("wanted no error. got %v", err)
+		}
+		if len(week.Done) != c.want {
+			t.Errorf("wanted %v entries. got %v", c.want, len(week.Done))
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package file
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	StringHeader = "string"
+	NumberHeader = "number"
+	IntHeader    = "int"
+	BoolHeader   = "bool"
+	DateHeader   = "date"
+)
+
+// HeaderField describes a week header in the header schema.
+type HeaderField struct {
+	Key string
+	// Type is "string" (the default), "number", "int", "bool" or "date".
+	Type *string
+	// Values are the allowed values. Any value is allowed when empty.
+	Values   []string
+	Required *bool
+	// Default is filled in by "week new" unless the header is carried
+	// over.
+	Default *string
+}
+
+func (c *FileConfig) GetHeaderSchema() []*HeaderField {
+	if c == nil {
+		return nil
+	}
+	return c.HeaderSchema
+}
+
+// HeaderField returns the schema of a header key, or nil when it isn't
+// in the schema.
+func (c *FileConfig) HeaderField(key string) *HeaderField {
+	for _, f := range c.GetHeaderSchema() {
+		if f.Key == key {
+			return f
+		}
+	}
+	return nil
+}
+
+// HeaderKeys lists the keys of the header schema in order.
+func (c *FileConfig) HeaderKeys() []string {
+	keys := []string{}
+	for _, f := range c.GetHeaderSchema() {
+		keys = append(keys, f.Key)
+	}
+	return keys
+}
+
+func (f *HeaderField) headerType() string {
+	if f.Type == nil {
+		return StringHeader
+	}
+	return *f.Type
+}
+
+func (f *HeaderField) IsRequired() bool {
+	return f.Required != nil && *f.Required
+}
+
+// ValidateHeader checks a header value against the type and allowed values of
+// the field.
+func (c *FileConfig) ValidateHeader(f *HeaderField, value string) error {
+	value = strings.TrimSpace(value)
+	var err error
+	switch f.headerType() {
+	case StringHeader:
+	case NumberHeader:
+		_, err = strconv.ParseFloat(value, 64)
+	case IntHeader:
+		_, err = strconv.Atoi(value)
+	case BoolHeader:
+		_, err = strconv.ParseBool(value)
+	case DateHeader:
+		_, err = c.parseDate(value)
+	default:
+		return fmt.Errorf("unknown type %q for %q header", f.headerType(), f.Key)
+	}
+	if err != nil {
+		return fmt.Errorf("malformed %q header: %q is not a %v", f.Key, value, f.headerType())
+	}
+	if len(f.Values) > 0 && !contains(f.Values, value) {
+		return fmt.Errorf("%q header %q is not one of %v", f.Key, value, strings.Join(f.Values, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package file
+
+import (
+	"testing"
+)
+
+func TestValidateHeader(t *testing.T) {
+	number, date := NumberHeader, DateHeader
+	days := &HeaderField{Key: "Days", Type: &number}
+	mood := &HeaderField{Key: "Mood", Values: []string{"good", "bad"}}
+	start := &HeaderField{Key: "Start", Type: &date}
+	cases := []struct {
+		field *HeaderField
+		value string
+		ok    bool
+	}{
+		{days, "4.5", true},
+		{days, "four", false},
+		{mood, "good", true},
+		{mood, "meh", false},
+		{start, "Nov 23 2020", true},
+		{start, "yesterday", false},
+	}
+	for _, c := range cases {
+		err := (*FileConfig)(nil).ValidateHeader(c.field, c.value)
+		if (err == nil) != c.ok {
+			t.Errorf("%v: %q: wanted ok %v. got %v", c.field.Key, c.value, c.ok, err)
+		}
+	}
+}
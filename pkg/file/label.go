@@ -0,0 +1,59 @@
+package file
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+// scanLabel returns the first label of line and the rest of the line. A
+// label ends at the first whitespace outside of quotes. A "\" before
+// anything which can't be escaped is kept as it is, so that values like
+// "C:\foo" read as they are written.
+func scanLabel(line string) (pair, rest string, err error) {
+	rs := []rune(line)
+	quoted := false
+	for i := 0; i < len(rs); i++ {
+		switch r := rs[i]; {
+		case r == '\\' && i+1 < len(rs) && types.IsEscapable(rs[i+1]):
+			i++
+		case r == '"':
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			return string(rs[:i]), string(rs[i:]), nil
+		}
+	}
+	if quoted {
+		return "", "", &labelError{pair: line, reason: "unterminated quote"}
+	}
+	return line, "", nil
+}
+
+// parseLabelValues splits a label value into its comma separated values,
+// removing quotes and escapes.
+func parseLabelValues(value string) ([]string, error) {
+	values := []string{}
+	current := strings.Builder{}
+	rs := []rune(value)
+	quoted := false
+	for i := 0; i < len(rs); i++ {
+		switch r := rs[i]; {
+		case r == '\\' && i+1 < len(rs) && types.IsEscapable(rs[i+1]):
+			i++
+			current.WriteRune(rs[i])
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			values = append(values, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, errors.New("unterminated quote")
+	}
+	return append(values, current.String()), nil
+}
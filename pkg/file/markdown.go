@@ -0,0 +1,298 @@
+package file
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+// Markdown logs have a "## Week of Nov 23 2020" heading for each week and
+// a GitHub-style task list item for each entry. Labels follow the item in
+// an HTML comment or after "##", e.g. "- [x] did thing <!-- cat=a -->".
+// Deeper headings naming a weekday start a day section, "Key: value" lines
+// right after the week heading are headers, and a heading at the week's
+// level or above ends the week. Everything else is left alone.
+
+var (
+	mdHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdWeek    = regexp.MustCompile(`^(?i:week of)\s+(.+)$`)
+	mdTask    = regexp.MustCompile(`^(\s*)([-*+])\s+\[([ xX])\]\s+(.*)$`)
+	mdComment = regexp.MustCompile(`^(.*?)\s*<!--\s*(.*?)\s*-->$`)
+	mdHeader  = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*):\s+(.*)$`)
+)
+
+func (c *FileConfig) GetMarkdownFiles() []string {
+	if c == nil {
+		return nil
+	}
+	return c.MarkdownFiles
+}
+
+func (c *FileConfig) ParseMarkdown(doc string) (types.Log, error) {
+	log, errs := c.parseMarkdown("", doc)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return log, nil
+}
+
+// mdLine is a line of a Markdown log which tf understands.
+type mdLine struct {
+	week    *time.Time
+	level   int
+	day     *time.Weekday
+	header  []string
+	entry   *types.Entry
+	done    bool
+	comment bool
+	indent  string
+	bullet  string
+	err     error
+}
+
+// mdScanner recognizes the lines of a Markdown log, one at a time.
+type mdScanner struct {
+	c *FileConfig
+	// canonical rewrites label aliases to their canonical values.
+	canonical bool
+	// level is the heading level of the current week, 0 outside of a week.
+	level    int
+	inHeader bool
+}
+
+func (s *mdScanner) scan(line string) *mdLine {
+	inHeader := s.inHeader
+	s.inHeader = false
+	if m := mdHeading.FindStringSubmatch(line); m != nil {
+		level := len(m[1])
+		if w := mdWeek.FindStringSubmatch(m[2]); w != nil {
+			t, err := s.c.parseDate(w[1])
+			if err != nil {
+				s.level = 0
+				return &mdLine{err: fmt.Errorf("invalid date %q: want a date like 'Nov 23 2020'", w[1])}
+			}
+			s.level, s.inHeader = level, true
+			return &mdLine{week: &t, level: level}
+		}
+		if s.level == 0 || level <= s.level {
+			s.level = 0
+			return nil
+		}
+		if d, ok := ParseDayMarker("--- " + m[2]); ok {
+			return &mdLine{day: &d}
+		}
+		return nil
+	}
+	if s.level == 0 {
+		return nil
+	}
+	if m := mdHeader.FindStringSubmatch(line); m != nil && inHeader {
+		s.inHeader = true
+		return &mdLine{header: m[1:]}
+	}
+	m := mdTask.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	text, comment := m[4], false
+	if cm := mdComment.FindStringSubmatch(text); cm != nil {
+		// Only a comment of labels holds the labels. Any other comment
+		// is part of the line.
+		if labels, err := s.c.parseLabels(cm[2]); err == nil && len(labels) > 0 {
+			text, comment = cm[1]+" ## "+cm[2], true
+		}
+	}
+	status := "[ ]"
+	if m[3] != " " {
+		status = "[x]"
+	}
+	entry, done, err := s.c.parseEntry(status + " " + text)
+	if err != nil {
+		return &mdLine{err: err}
+	}
+	if s.canonical {
+		s.c.canonicalize(entry)
+	}
+	return &mdLine{
+		entry:   entry,
+		done:    done,
+		comment: comment,
+		indent:  m[1],
+		bullet:  m[2],
+	}
+}
+
+func (c *FileConfig) parseMarkdown(filename, doc string) (types.Log, ParseErrors) {
+	log := types.Log{}
+	var errs ParseErrors
+	var week *types.Week
+	var day time.Time
+	s := &mdScanner{c: c, canonical: true}
+	for i, line := range strings.Split(doc, "\n") {
+		pos := types.Position{
+			File:   filename,
+			Record: len(log),
+			Line:   i + 1,
+			Column: 1,
+		}
+		l := s.scan(line)
+		if s.level == 0 {
+			week = nil
+		}
+		switch {
+		case l == nil:
+		case l.err != nil:
+			errs = append(errs, &ParseError{Pos: pos, Msg: l.err.Error()})
+		case l.week != nil:
+			week = &types.Week{
+				Date:   *l.week,
+				Header: map[string][]string{},
+				Done:   []*types.Entry{},
+				Todo:   []*types.Entry{},
+				Pos:    pos,
+			}
+			week.Pos.Record = len(log) + 1
+			log = append(log, week)
+			day = time.Time{}
+		case l.day != nil:
+			day = DayOf(week.Date, *l.day)
+		case l.header != nil:
+			week.Header[l.header[0]] = append(week.Header[l.header[0]], l.header[1])
+		case l.entry != nil:
+			l.entry.Pos = pos
+			l.entry.Pos.Column = len(l.indent) + 1
+			l.entry.Date = day
+			if l.done {
+				week.Done = append(week.Done, l.entry)
+			} else {
+				week.Todo = append(week.Todo, l.entry)
+			}
+		}
+	}
+	return log, errs
+}
+
+// TidyMarkdown formats the week headings and task list items of a Markdown
+// log. Labels are sorted and lined up within each week, keeping the style
+// they were written in, and with canonical label aliases are rewritten to
+// their canonical values. Nothing else in the document is changed.
+func (c *FileConfig) TidyMarkdown(doc string, canonical bool) string {
+	lines := strings.Split(doc, "\n")
+	parsed := make([]*mdLine, len(lines))
+	s := &mdScanner{c: c, canonical: canonical}
+	for i, line := range lines {
+		parsed[i] = s.scan(line)
+	}
+	for start := 0; start < len(lines); {
+		end := start + 1
+		for end < len(lines) && (parsed[end] == nil || parsed[end].week == nil) {
+			end++
+		}
+		width := 0
+		for i := start; i < end; i++ {
+			if l := parsed[i]; l != nil && l.entry != nil && len(l.indent+l.entry.Line) > width {
+				width = len(l.indent + l.entry.Line)
+			}
+		}
+		for i := start; i < end; i++ {
+			l := parsed[i]
+			switch {
+			case l == nil || l.err != nil:
+			case l.week != nil:
+				lines[i] = fmt.Sprintf("%v Week of %v", strings.Repeat("#", l.level), l.week.Format(dateFormat))
+			case l.entry != nil:
+				lines[i] = sprintMarkdownEntry(l.indent, l.bullet, l.entry, l.done, l.comment, width)
+			}
+		}
+		start = end
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SprintMarkdown formats a log as Markdown, newest week first, with labels
+// in HTML comments.
+func (c *FileConfig) SprintMarkdown(log types.Log) string {
+	sort.Slice(log, func(i, j int) bool { return log[i].Date.After(log[j].Date) })
+	out := ""
+	for i, week := range log {
+		if i > 0 {
+			out += "\n"
+		}
+		// Headers must follow the heading directly to be read back.
+		out += fmt.Sprintf("## Week of %v\n", week.Date.Format(dateFormat))
+		keys := []string{}
+		for k := range week.Header {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			for _, v := range week.Header[k] {
+				out += fmt.Sprintf("%v: %v\n", k, v)
+			}
+		}
+		width := 0
+		for _, entry := range append(append([]*types.Entry{}, week.Done...), week.Todo...) {
+			if len(entry.Line) > width {
+				width = len(entry.Line)
+			}
+		}
+		days := []time.Time{}
+		done := map[time.Time][]*types.Entry{}
+		todo := map[time.Time][]*types.Entry{}
+		for _, entry := range week.Done {
+			if len(done[entry.Date])+len(todo[entry.Date]) == 0 {
+				days = append(days, entry.Date)
+			}
+			done[entry.Date] = append(done[entry.Date], entry)
+		}
+		for _, entry := range week.Todo {
+			if len(done[entry.Date])+len(todo[entry.Date]) == 0 {
+				days = append(days, entry.Date)
+			}
+			todo[entry.Date] = append(todo[entry.Date], entry)
+		}
+		sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+		for _, day := range days {
+			out += "\n"
+			if !day.IsZero() {
+				out += fmt.Sprintf("### %v\n\n", day.Format("Monday"))
+			}
+			for _, entry := range done[day] {
+				out += sprintMarkdownEntry("", "-", entry, true, true, width) + "\n"
+			}
+			for _, entry := range todo[day] {
+				out += sprintMarkdownEntry("", "-", entry, false, true, width) + "\n"
+			}
+		}
+	}
+	return out
+}
+
+func sprintMarkdownEntry(indent, bullet string, entry *types.Entry, done, comment bool, width int) string {
+	status := "[ ]"
+	if done {
+		status = "[x]"
+	}
+	out := fmt.Sprintf("%v%v %v %v", indent, bullet, status, entry.Line)
+	if len(entry.Labels) == 0 {
+		return out
+	}
+	keys := []string{}
+	for k := range entry.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	labels := ""
+	for _, k := range keys {
+		labels += " " + types.FormatLabel(k, entry.Labels[k])
+	}
+	out += strings.Repeat(" ", width-len(indent)-len(entry.Line))
+	if comment {
+		return out + "  <!--" + labels + " -->"
+	}
+	return out + "  ##" + labels
+}
@@ -0,0 +1,108 @@
+package file
+
+import (
+	"testing"
+	"time"
+)
+
+const markdownLog = `# Notes
+
+## Week of November 23, 2020
+Days: 4
+
+- [x] fixed the bug <!-- sub=ops cat=customer -->
+- [X] long review ## cat=primary
+- plain bullet
+* [ ] write docs
++ [ ] read <!-- from the list -->
+
+### Wed
+
+  - [x] pairing <!-- cat=community -->
+
+## Other stuff
+
+- [x] ignored
+`
+
+func TestParseMarkdown(t *testing.T) {
+	log, err := (*FileConfig)(nil).ParseMarkdown(markdownLog)
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if len(log) != 1 {
+		t.Fatalf("wanted 1 week. got %v", len(log))
+	}
+	week := log[0]
+	if want := time.Date(2020, time.November, 23, 0, 0, 0, 0, time.UTC); !week.Date.Equal(want) {
+		t.Errorf("wanted week of %v. got %v", want, week.Date)
+	}
+	if got := week.Header["Days"]; len(got) != 1 || got[0] != "4" {
+		t.Errorf("wanted Days header 4. got %v", got)
+	}
+	if len(week.Done) != 3 || len(week.Todo) != 2 {
+		t.Fatalf("wanted 3 done and 2 todo entries. got %v and %v", len(week.Done), len(week.Todo))
+	}
+	if got := week.Done[0].Labels; got["cat"] != "customer" || got["sub"] != "ops" {
+		t.Errorf("wanted labels from the comment. got %v", got)
+	}
+	if got := week.Done[1].Line; got != "long review" {
+		t.Errorf("wanted line %q. got %q", "long review", got)
+	}
+	if got := week.Todo[1]; got.Line != "read <!-- from the list -->" || len(got.Labels) != 0 {
+		t.Errorf("wanted the comment in the line. got %q with labels %v", got.Line, got.Labels)
+	}
+	pairing := week.Done[2]
+	if want := time.Date(2020, time.November, 25, 0, 0, 0, 0, time.UTC); !pairing.Date.Equal(want) {
+		t.Errorf("wanted day %v. got %v", want, pairing.Date)
+	}
+	if got := pairing.Pos.String(); got != "log:14:3" {
+		t.Errorf("wanted position log:14:3. got %v", got)
+	}
+}
+
+func TestTidyMarkdown(t *testing.T) {
+	want := `# Notes
+
+## Week of Nov 23 2020
+Days: 4
+
+- [x] fixed the bug                <!-- cat=customer sub=ops -->
+- [x] long review                  ## cat=primary
+- plain bullet
+* [ ] write docs
++ [ ] read <!-- from the list -->
+
+### Wed
+
+  - [x] pairing                    <!-- cat=community -->
+
+## Other stuff
+
+- [x] ignored
+`
+	var c *FileConfig
+	got := c.TidyMarkdown(markdownLog, false)
+	if got != want {
+		t.Errorf("wanted %q. got %q", want, got)
+	}
+	if again := c.TidyMarkdown(got, false); again != got {
+		t.Errorf("wanted tidy to be stable. got %q", again)
+	}
+}
+
+func TestSprintMarkdownRoundTrip(t *testing.T) {
+	var c *FileConfig
+	log, err := c.ParseMarkdown(markdownLog)
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	doc := c.SprintMarkdown(log)
+	again, err := c.ParseMarkdown(doc)
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if doc2 := c.SprintMarkdown(again); doc2 != doc {
+		t.Errorf("wanted %q. got %q", doc, doc2)
+	}
+}
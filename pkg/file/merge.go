@@ -6,68 +6,114 @@ import (
 	"github.com/josephburnett/time-flies/pkg/types"
 )
 
+// mergeLogs merges the weeks of b into a. Weeks keep the order in which
+// they are first seen, a's weeks before b's new weeks.
 func mergeLogs(a, b types.Log) types.Log {
-	weeks := map[time.Time]*types.Week{}
-	for _, w1 := range a {
-		key := w1.Date.Round(0)
-		w2 := weeks[key]
-		weeks[key] = mergeWeeks(w1, w2)
-	}
-	for _, w1 := range b {
-		key := w1.Date.Round(0)
-		w2 := weeks[key]
-		weeks[key] = mergeWeeks(w1, w2)
-	}
-	var log types.Log
-	for _, w := range weeks {
-		log = append(log, w)
+	log := types.Log{}
+	weeks := map[int64]*types.Week{}
+	for _, w := range append(append(types.Log{}, a...), b...) {
+		key := weekKey(w.Date)
+		if merged, ok := weeks[key]; ok {
+			mergeWeek(merged, w)
+			continue
+		}
+		merged := &types.Week{
+			Date:   w.Date,
+			Header: map[string][]string{},
+			Done:   []*types.Entry{},
+			Todo:   []*types.Entry{},
+			Pos:    w.Pos,
+		}
+		mergeWeek(merged, w)
+		weeks[key] = merged
+		log = append(log, merged)
 	}
 	return log
 }
 
-func mergeWeeks(a, b *types.Week) *types.Week {
-	ab := &types.Week{}
-	if a != nil {
-		ab.Header = mergeHeaders(ab.Header, a.Header)
-		ab.Date = a.Date
-		for _, d := range a.Done {
-			ab.Done = append(ab.Done, d)
-		}
-		for _, t := range a.Todo {
-			ab.Todo = append(ab.Todo, t)
-		}
-	}
-	if b != nil {
-		ab.Header = mergeHeaders(ab.Header, b.Header)
-		ab.Date = b.Date
-		for _, d := range b.Done {
-			ab.Done = append(ab.Done, d)
-		}
-		for _, t := range b.Todo {
-			ab.Todo = append(ab.Todo, t)
-		}
-	}
-	return ab
+// weekKey is the same for equal dates in any location.
+func weekKey(t time.Time) int64 {
+	return t.Unix()
+}
+
+// mergeWeek adds the headers and entries of b to a.
+func mergeWeek(a, b *types.Week) {
+	a.Header = mergeHeaders(a.Header, b.Header)
+	a.Done = mergeEntries(a.Done, b.Done)
+	a.Todo = mergeEntries(a.Todo, b.Todo)
 }
 
+// mergeHeaders returns the union of a and b. Values of each key keep
+// their order, a's before b's, without repeats.
 func mergeHeaders(a, b map[string][]string) map[string][]string {
 	ab := map[string][]string{}
-	for ka, vsa := range a {
-		var vsab []string
-		copy(vsab, vsa)
-		vsb := b[ka]
-		for _, vb := range vsb {
-			var have bool
-			for _, vab := range vsab {
-				if vab == vb {
-					have = true
+	for _, h := range []map[string][]string{a, b} {
+		for k, vs := range h {
+			for _, v := range vs {
+				if !contains(ab[k], v) {
+					ab[k] = append(ab[k], v)
 				}
 			}
-			if !have {
-				vsab = append(vsab, vb)
+		}
+	}
+	return ab
+}
+
+// mergeEntries appends the entries of b to a. An entry of b which is the
+// same as an entry of a from another source is not added again, its
+// sources are recorded on the entry of a instead. Repeated entries from
+// the same source are kept since the same thing can be done twice.
+func mergeEntries(a, b []*types.Entry) []*types.Entry {
+	ab := append([]*types.Entry{}, a...)
+	matched := map[*types.Entry]bool{}
+	for _, eb := range b {
+		var dup *types.Entry
+		for _, ea := range a {
+			if !matched[ea] && sameEntry(ea, eb) && !shareSource(ea, eb) {
+				dup = ea
+				break
+			}
+		}
+		if dup == nil {
+			ab = append(ab, eb)
+			continue
+		}
+		matched[dup] = true
+		for _, s := range eb.Sources {
+			if !contains(dup.Sources, s) {
+				dup.Sources = append(dup.Sources, s)
 			}
 		}
-		ab[ka] = vsab
 	}
 	return ab
 }
+
+func sameEntry(a, b *types.Entry) bool {
+	if a.Line != b.Line || !a.Date.Equal(b.Date) || len(a.Labels) != len(b.Labels) {
+		return false
+	}
+	for k, v := range a.Labels {
+		if bv, ok := b.Labels[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+func shareSource(a, b *types.Entry) bool {
+	for _, s := range b.Sources {
+		if contains(a.Sources, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package file
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+func TestMergeLogs(t *testing.T) {
+	week := func(day int, header map[string][]string, source string, lines ...string) *types.Week {
+		w := &types.Week{
+			Date:   time.Date(2020, time.November, day, 0, 0, 0, 0, time.UTC),
+			Header: header,
+		}
+		for _, line := range lines {
+			w.Done = append(w.Done, &types.Entry{
+				Line:    line,
+				Labels:  map[string]string{"cat": "a"},
+				Sources: []string{source},
+			})
+		}
+		return w
+	}
+	a := types.Log{
+		week(30, nil, "log", "new"),
+		week(23, map[string][]string{"Days": {"4"}}, "log", "standup", "standup", "shared"),
+	}
+	b := types.Log{
+		week(16, nil, "org", "old"),
+		week(23, map[string][]string{"Days": {"4", "3"}, "Hours": {"30"}}, "org", "shared", "standup"),
+	}
+	log := mergeLogs(a, b)
+	days := []int{}
+	for _, w := range log {
+		days = append(days, w.Date.Day())
+	}
+	if want := []int{30, 23, 16}; !reflect.DeepEqual(days, want) {
+		t.Errorf("wanted weeks %v. got %v", want, days)
+	}
+	merged := log[1]
+	wantHeader := map[string][]string{"Days": {"4", "3"}, "Hours": {"30"}}
+	if !reflect.DeepEqual(merged.Header, wantHeader) {
+		t.Errorf("wanted header %v. got %v", wantHeader, merged.Header)
+	}
+	got := [][]string{}
+	for _, e := range merged.Done {
+		got = append(got, append([]string{e.Line}, e.Sources...))
+	}
+	want := [][]string{
+		{"standup", "log", "org"},
+		{"standup", "log"},
+		{"shared", "log", "org"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wanted entries %v. got %v", want, got)
+	}
+}
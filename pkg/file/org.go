@@ -2,8 +2,11 @@ package file
 
 import (
 	"bytes"
-	"io/ioutil"
+	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/josephburnett/time-flies/pkg/types"
 	"github.com/niklasfasching/go-org/org"
@@ -17,85 +20,230 @@ func (c *FileConfig) GetOrgFiles() []string {
 }
 
 func (c *FileConfig) ReadOrg() (types.Log, error) {
-	var allLogs types.Log
+	orgType := orgSourceType
+	sources := []*SourceConfig{}
 	for _, f := range c.GetOrgFiles() {
-		bytes, err := ioutil.ReadFile(f)
-		if err != nil {
-			return nil, err
-		}
-		log, err := c.ParseOrg(string(bytes))
-		if err != nil {
-			return nil, err
-		}
-		allLogs = mergeLogs(allLogs, log)
+		sources = append(sources, &SourceConfig{Path: f, Type: &orgType})
 	}
-	return allLogs, nil
+	return c.readSources(sources)
+}
+
+var (
+	orgClosed    = regexp.MustCompile(`CLOSED:\s*\[(\d{4}-\d{2}-\d{2})[^\]]*\]`)
+	orgScheduled = regexp.MustCompile(`SCHEDULED:\s*<(\d{4}-\d{2}-\d{2})[^>]*>`)
+	orgClock     = regexp.MustCompile(`CLOCK:\s*\[[^\]]*\]--\[[^\]]*\]\s*=>\s*(\d+):(\d{2})`)
+	orgDate      = regexp.MustCompile(`(\d{4}-\d{2}-\d{2})`)
+)
+
+const (
+	orgDateFormat = "2006-01-02"
+)
+
+// orgIgnoredProperties are not turned into labels.
+var orgIgnoredProperties = map[string]bool{
+	"ID":        true,
+	"CUSTOM_ID": true,
+	"CREATED":   true,
+	"COMPLETED": true,
+	"EFFORT":    true,
+	"CATEGORY":  true,
 }
 
+// ParseOrg reads TODO and DONE headlines as entries. Entries are bucketed
+// into weeks by their CLOSED or SCHEDULED timestamps, falling back to the
+// COMPLETED or CREATED properties. Undated entries are kept in a week
+// without a date.
 func (c *FileConfig) ParseOrg(doc string) (types.Log, error) {
 	d := org.New().Parse(bytes.NewReader([]byte(doc)), "")
-	done, todo, err := sectionEntries(d.Outline.Section)
+	entries, err := sectionEntries(d.Outline.Section)
 	if err != nil {
 		return nil, err
 	}
-	return types.Log{&types.Week{
-		// TODO: date from COMPLETED or CREATED
-		// TODO: header from PROPERTIES
-		Done: done,
-		Todo: todo,
-	}}, nil
+	log := types.Log{}
+	weeks := map[time.Time]*types.Week{}
+	for _, e := range entries {
+		c.canonicalize(e.entry)
+		date := time.Time{}
+		if !e.entry.Date.IsZero() {
+			date = c.WeekOf(e.entry.Date)
+		}
+		week, ok := weeks[date]
+		if !ok {
+			week = &types.Week{
+				Date:   date,
+				Header: map[string][]string{},
+				Done:   []*types.Entry{},
+				Todo:   []*types.Entry{},
+			}
+			weeks[date] = week
+			log = append(log, week)
+		}
+		if e.done {
+			week.Done = append(week.Done, e.entry)
+		} else {
+			week.Todo = append(week.Todo, e.entry)
+		}
+	}
+	return log, nil
+}
+
+type orgEntry struct {
+	entry *types.Entry
+	done  bool
 }
 
-func sectionEntries(s *org.Section) (done, todo []*types.Entry, err error) {
+func sectionEntries(s *org.Section) (entries []*orgEntry, err error) {
 	if s == nil {
 		return
 	}
-	if s.Headline != nil {
-		if s.Headline.Status == "TODO" {
-			lines := []string{}
-			for _, n := range s.Headline.Title {
-				lines = append(lines, n.String())
-			}
-			todo = append(todo, &types.Entry{
-				Line:   strings.Join(lines, " "),
-				Labels: tagLabels(s.Headline.Tags),
-			})
+	if h := s.Headline; h != nil && (h.Status == "TODO" || h.Status == "DONE") {
+		lines := []string{}
+		for _, n := range h.Title {
+			lines = append(lines, n.String())
 		}
-		if s.Headline.Status == "DONE" {
-			lines := []string{}
-			for _, n := range s.Headline.Title {
-				lines = append(lines, n.String())
-			}
-			done = append(done, &types.Entry{
-				Line:   strings.Join(lines, " "),
-				Labels: tagLabels(s.Headline.Tags),
-			})
+		done := h.Status == "DONE"
+		entry := &types.Entry{
+			Line:   strings.Join(lines, " "),
+			Labels: tagLabels(h.Tags),
+		}
+		err := headlineDetails(h, done, entry)
+		if err != nil {
+			return nil, err
 		}
+		entries = append(entries, &orgEntry{
+			entry: entry,
+			done:  done,
+		})
 	}
 	for _, c := range s.Children {
-		cDone, cTodo, err := sectionEntries(c)
+		cEntries, err := sectionEntries(c)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
-		done = append(done, cDone...)
-		todo = append(todo, cTodo...)
+		entries = append(entries, cEntries...)
 	}
 	return
 }
 
-func tagLabels(tags []string) map[string]string {
-	labels := map[string]string{}
-	for _, t := range tags {
-		t = strings.ReplaceAll(t, "_", "-")
-		parts := strings.Split(t, "@")
-		if len(parts) != 2 {
+// headlineDetails fills in the date of an entry from planning lines and
+// properties, adds PROPERTIES as labels and turns CLOCK lines and EFFORT
+// into "t" and "f" labels. Tags take precedence over properties.
+func headlineDetails(h *org.Headline, done bool, entry *types.Entry) error {
+	properties := [][]string{}
+	if h.Properties != nil {
+		properties = append(properties, h.Properties.Properties...)
+	}
+	text := ""
+	for _, n := range h.Children {
+		switch n := n.(type) {
+		case org.Headline:
+			// Children are entries of their own.
+		case org.PropertyDrawer:
+			properties = append(properties, n.Properties...)
+		default:
+			text += n.String() + "\n"
+		}
+	}
+	property := map[string]string{}
+	for _, kv := range properties {
+		if len(kv) != 2 {
 			continue
 		}
-		k, v := parts[0], parts[1]
-		if k == "" || v == "" {
+		k, v := strings.ToUpper(kv[0]), strings.TrimSpace(kv[1])
+		property[k] = v
+		if orgIgnoredProperties[k] || v == "" {
 			continue
 		}
-		labels[k] = v
+		label := strings.ReplaceAll(strings.ToLower(k), "_", "-")
+		if _, ok := entry.Labels[label]; !ok {
+			entry.Labels[label] = v
+		}
+	}
+	dates := []string{}
+	if m := orgClosed.FindStringSubmatch(text); m != nil && done {
+		dates = append(dates, m[1])
+	}
+	if m := orgScheduled.FindStringSubmatch(text); m != nil {
+		dates = append(dates, m[1])
+	}
+	if m := orgDate.FindStringSubmatch(property["COMPLETED"]); m != nil && done {
+		dates = append(dates, m[1])
+	}
+	if m := orgDate.FindStringSubmatch(property["CREATED"]); m != nil {
+		dates = append(dates, m[1])
+	}
+	if len(dates) > 0 {
+		date, err := time.Parse(orgDateFormat, dates[0])
+		if err != nil {
+			return err
+		}
+		entry.Date = date
+	}
+	var clocked time.Duration
+	for _, m := range orgClock.FindAllStringSubmatch(text, -1) {
+		hours, _ := strconv.Atoi(m[1])
+		minutes, _ := strconv.Atoi(m[2])
+		clocked += time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+	}
+	if _, ok := entry.Labels["t"]; !ok && clocked > 0 {
+		entry.Labels["t"] = formatDuration(clocked)
+	}
+	if effort, ok := property["EFFORT"]; ok {
+		if _, ok := entry.Labels["f"]; !ok {
+			d, err := parseEffort(effort)
+			if err != nil {
+				return err
+			}
+			entry.Labels["f"] = formatDuration(d)
+		}
+	}
+	return nil
+}
+
+// parseEffort parses an org effort like "1:30" or a Go duration like "90m".
+func parseEffort(effort string) (time.Duration, error) {
+	if parts := strings.Split(effort, ":"); len(parts) == 2 {
+		hours, err1 := strconv.Atoi(parts[0])
+		minutes, err2 := strconv.Atoi(parts[1])
+		if err1 == nil && err2 == nil {
+			return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+		}
+	}
+	d, err := time.ParseDuration(effort)
+	if err != nil {
+		return 0, fmt.Errorf("malformed EFFORT %q", effort)
+	}
+	return d, nil
+}
+
+// formatDuration formats d like "1h30m", without zero units.
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+func tagLabels(tags []string) map[string]string {
+	labels := map[string]string{}
+	for _, t := range tags {
+		if k, v, ok := tagLabel(t); ok {
+			labels[k] = v
+		}
 	}
 	return labels
 }
+
+// tagLabel parses a label tag like "cat@customer_ops", where "_" stands
+// for "-".
+func tagLabel(tag string) (k, v string, ok bool) {
+	parts := strings.Split(strings.ReplaceAll(tag, "_", "-"), "@")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,59 @@
+package file
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOrg(t *testing.T) {
+	doc := `* Work
+** DONE fix the bug :cat@customer:sub@ops:
+   CLOSED: [2020-11-25 Wed 10:00]
+   :PROPERTIES:
+   :EFFORT:   1:30
+   :PROJ:     thing-one
+   :END:
+   :LOGBOOK:
+   CLOCK: [2020-11-25 Wed 08:00]--[2020-11-25 Wed 09:15] =>  1:15
+   :END:
+** TODO write docs :cat@community:
+   SCHEDULED: <2020-12-01 Tue>
+** TODO undated thing
+`
+	log, err := (*FileConfig)(nil).ParseOrg(doc)
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if len(log) != 3 {
+		t.Fatalf("wanted 3 weeks. got %v", len(log))
+	}
+	done := log[0]
+	if want := time.Date(2020, time.November, 23, 0, 0, 0, 0, time.UTC); !done.Date.Equal(want) {
+		t.Errorf("wanted week of %v. got %v", want, done.Date)
+	}
+	if len(done.Done) != 1 {
+		t.Fatalf("wanted 1 done entry. got %v", len(done.Done))
+	}
+	wantLabels := map[string]string{
+		"cat":  "customer",
+		"sub":  "ops",
+		"f":    "1h30m",
+		"t":    "1h15m",
+		"proj": "thing-one",
+	}
+	labels := done.Done[0].Labels
+	if len(labels) != len(wantLabels) {
+		t.Errorf("wanted labels %v. got %v", wantLabels, labels)
+	}
+	for k, v := range wantLabels {
+		if labels[k] != v {
+			t.Errorf("wanted label %v=%v. got %v", k, v, labels[k])
+		}
+	}
+	if want := time.Date(2020, time.November, 30, 0, 0, 0, 0, time.UTC); !log[1].Date.Equal(want) || len(log[1].Todo) != 1 {
+		t.Errorf("wanted TODO in week of %v. got %v", want, log[1].Date)
+	}
+	if !log[2].Date.IsZero() || len(log[2].Todo) != 1 {
+		t.Errorf("wanted undated TODO in a week without a date. got %v", log[2].Date)
+	}
+}
@@ -0,0 +1,235 @@
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+// Org files are edited as text so that everything tf doesn't own is kept
+// byte for byte. tf owns the status, tags and CLOSED timestamp of TODO and
+// DONE headlines.
+
+var (
+	orgHeadline = regexp.MustCompile(`^(\*+)\s+(TODO|DONE)\s+(.*?)(?:\s+(:[A-Za-z0-9_@#%:]+:))?\s*$`)
+	orgTag      = regexp.MustCompile(`^[A-Za-z0-9_@#%]+$`)
+	orgPlanning = regexp.MustCompile(`^\s*(CLOSED|SCHEDULED|DEADLINE):`)
+)
+
+const (
+	orgTimestampFormat = "2006-01-02 Mon 15:04"
+)
+
+type orgHeadlineLine struct {
+	stars  string
+	status string
+	title  string
+	tags   []string
+}
+
+func parseOrgHeadline(line string) *orgHeadlineLine {
+	m := orgHeadline.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	h := &orgHeadlineLine{
+		stars:  m[1],
+		status: m[2],
+		title:  m[3],
+	}
+	if m[4] != "" {
+		h.tags = strings.FieldsFunc(m[4], func(r rune) bool { return r == ':' })
+	}
+	return h
+}
+
+func (h *orgHeadlineLine) String() string {
+	s := fmt.Sprintf("%v %v %v", h.stars, h.status, h.title)
+	if len(h.tags) > 0 {
+		s += " :" + strings.Join(h.tags, ":") + ":"
+	}
+	return s
+}
+
+// labelTags returns labels as org tags like "cat@customer", and the
+// labels which can't be written as tags. Values containing "_" can't be
+// tags since "_" in a tag is read as "-".
+func labelTags(labels map[string]string) (tags []string, properties map[string]string) {
+	properties = map[string]string{}
+	keys := []string{}
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		tag := strings.ReplaceAll(k+"@"+labels[k], "-", "_")
+		if strings.Count(tag, "@") != 1 || !orgTag.MatchString(tag) || strings.Contains(labels[k], "_") {
+			properties[k] = labels[k]
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags, properties
+}
+
+// normalizeTags keeps plain tags in their order and follows them with
+// label tags sorted by key, dropping label tags repeated for a key. Tags
+// which aren't labels, such as "@home", are plain tags. It reports
+// whether anything changed.
+func (h *orgHeadlineLine) normalizeTags() bool {
+	plain := []string{}
+	labels := map[string]string{}
+	keys := []string{}
+	for _, t := range h.tags {
+		k, _, ok := tagLabel(t)
+		if !ok {
+			plain = append(plain, t)
+			continue
+		}
+		if _, seen := labels[k]; !seen {
+			keys = append(keys, k)
+		}
+		// The last tag for a key wins, as when the tags are read.
+		labels[k] = t
+	}
+	sort.Strings(keys)
+	tags := plain
+	for _, k := range keys {
+		tags = append(tags, labels[k])
+	}
+	changed := len(tags) != len(h.tags)
+	for i := range tags {
+		if !changed && tags[i] != h.tags[i] {
+			changed = true
+		}
+	}
+	h.tags = tags
+	return changed
+}
+
+// canonicalizeTags rewrites the values of label tags to their canonical
+// spelling, where that can be a tag. It reports whether anything changed.
+func (h *orgHeadlineLine) canonicalizeTags(c *FileConfig) bool {
+	changed := false
+	for i, t := range h.tags {
+		k, v, ok := tagLabel(t)
+		if !ok {
+			continue
+		}
+		tags, _ := labelTags(map[string]string{k: c.canonicalLabel(k, v)})
+		if len(tags) == 1 && tags[0] != t {
+			h.tags[i] = tags[0]
+			changed = true
+		}
+	}
+	return changed
+}
+
+// TidyOrg normalizes the tags of TODO and DONE headlines, and with
+// canonical rewrites label aliases to their canonical values. Nothing else
+// in the document is changed.
+func (c *FileConfig) TidyOrg(doc string, canonical bool) string {
+	lines := strings.Split(doc, "\n")
+	for i, line := range lines {
+		h := parseOrgHeadline(line)
+		if h == nil {
+			continue
+		}
+		changed := canonical && h.canonicalizeTags(c)
+		if h.normalizeTags() || changed {
+			lines[i] = h.String()
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SprintOrgEntry formats an entry as a top-level org headline. Labels
+// which can't be tags go in a PROPERTIES drawer.
+func (c *FileConfig) SprintOrgEntry(entry *types.Entry, done bool, now time.Time) string {
+	tags, properties := labelTags(entry.Labels)
+	h := &orgHeadlineLine{
+		stars:  "*",
+		status: "TODO",
+		title:  entry.Line,
+		tags:   tags,
+	}
+	if done {
+		h.status = "DONE"
+	}
+	out := h.String() + "\n"
+	if done {
+		out += fmt.Sprintf("  CLOSED: [%v]\n", now.Format(orgTimestampFormat))
+	}
+	if len(properties) > 0 {
+		out += "  :PROPERTIES:\n"
+		keys := []string{}
+		for k := range properties {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			out += fmt.Sprintf("  :%v: %v\n", strings.ToUpper(k), properties[k])
+		}
+		out += "  :END:\n"
+	}
+	return out
+}
+
+// AddOrgEntry validates line and appends it to an org file as a top-level
+// headline.
+func (c *FileConfig) AddOrgEntry(filename, line string, now time.Time) error {
+	entry, done, err := c.ParseEntry(line)
+	if err != nil {
+		return err
+	}
+	bs, err := ioutil.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	doc := string(bs)
+	if doc != "" && !strings.HasSuffix(doc, "\n") {
+		doc += "\n"
+	}
+	doc += c.SprintOrgEntry(entry, done, now)
+	return writeFile(filename, []byte(doc))
+}
+
+// completeOrg marks the TODO headline whose title contains match as DONE
+// and adds a CLOSED timestamp. It returns the number of matching TODO
+// headlines; the document is only changed when there is exactly one.
+func completeOrg(doc, match string, now time.Time) (string, int) {
+	lines := strings.Split(doc, "\n")
+	found := -1
+	count := 0
+	for i, line := range lines {
+		h := parseOrgHeadline(line)
+		if h == nil || h.status != "TODO" || !strings.Contains(h.title, match) {
+			continue
+		}
+		found = i
+		count++
+	}
+	if count != 1 {
+		return doc, count
+	}
+	h := parseOrgHeadline(lines[found])
+	h.status = "DONE"
+	lines[found] = h.String()
+	closed := fmt.Sprintf("CLOSED: [%v]", now.Format(orgTimestampFormat))
+	indent := strings.Repeat(" ", len(h.stars)+1)
+	if found+1 < len(lines) && orgPlanning.MatchString(lines[found+1]) {
+		planning := lines[found+1]
+		indent = planning[:len(planning)-len(strings.TrimLeft(planning, " \t"))]
+		lines[found+1] = indent + closed + " " + strings.TrimSpace(planning)
+	} else {
+		rest := append([]string{indent + closed}, lines[found+1:]...)
+		lines = append(lines[:found+1], rest...)
+	}
+	return strings.Join(lines, "\n"), 1
+}
@@ -0,0 +1,120 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCompleteOrg(t *testing.T) {
+	now := time.Date(2020, time.November, 25, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name  string
+		doc   string
+		match string
+		count int
+		want  string
+	}{{
+		name:  "adds closed",
+		doc:   "* Work\n** TODO fix the bug :cat@ops:\n   :PROPERTIES:\n   :END:\n",
+		match: "bug",
+		count: 1,
+		want:  "* Work\n** DONE fix the bug :cat@ops:\n   CLOSED: [2020-11-25 Wed 10:00]\n   :PROPERTIES:\n   :END:\n",
+	}, {
+		name:  "merges planning",
+		doc:   "* TODO write docs\n  SCHEDULED: <2020-12-01 Tue>\n",
+		match: "docs",
+		count: 1,
+		want:  "* DONE write docs\n  CLOSED: [2020-11-25 Wed 10:00] SCHEDULED: <2020-12-01 Tue>\n",
+	}, {
+		name:  "ambiguous",
+		doc:   "* TODO fix one\n* TODO fix two\n",
+		match: "fix",
+		count: 2,
+		want:  "* TODO fix one\n* TODO fix two\n",
+	}, {
+		name:  "already done",
+		doc:   "* DONE fix one\n",
+		match: "fix",
+		count: 0,
+		want:  "* DONE fix one\n",
+	}}
+	for _, c := range cases {
+		got, count := completeOrg(c.doc, c.match, now)
+		if count != c.count {
+			t.Errorf("%v: wanted %v matches. got %v", c.name, c.count, count)
+		}
+		if got != c.want {
+			t.Errorf("%v: wanted %q. got %q", c.name, c.want, got)
+		}
+	}
+}
+
+func TestTidyOrg(t *testing.T) {
+	cases := []struct {
+		doc  string
+		want string
+	}{{
+		doc:  "* Notes :b@x:\n** TODO thing :sub@ops:urgent:cat@customer_ops:\n",
+		want: "* Notes :b@x:\n** TODO thing :urgent:cat@customer_ops:sub@ops:\n",
+	}, {
+		doc:  "** TODO call mom :@home:cat@family:\n** TODO x :a@b@c:work:\n",
+		want: "** TODO call mom :@home:cat@family:\n** TODO x :a@b@c:work:\n",
+	}, {
+		doc:  "** TODO y :cat@b:@home:sub@c:cat@a:\n",
+		want: "** TODO y :@home:cat@a:sub@c:\n",
+	}}
+	for _, c := range cases {
+		if got := (*FileConfig)(nil).TidyOrg(c.doc, false); got != c.want {
+			t.Errorf("wanted %q. got %q", c.want, got)
+		}
+	}
+}
+
+func TestSprintOrgEntryRoundTrip(t *testing.T) {
+	var c *FileConfig
+	now := time.Date(2020, time.November, 25, 10, 0, 0, 0, time.UTC)
+	entry, done, err := c.ParseEntry(`[x] fix the bug ## cat=customer-ops sub=my_thing t=1h url=a/b note="pairing session"`)
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	log, err := c.ParseOrg(c.SprintOrgEntry(entry, done, now))
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if len(log) != 1 || len(log[0].Done) != 1 {
+		t.Fatalf("wanted 1 done entry. got %v", log)
+	}
+	got := log[0].Done[0]
+	if got.Line != entry.Line {
+		t.Errorf("wanted line %q. got %q", entry.Line, got.Line)
+	}
+	for k, v := range entry.Labels {
+		if got.Labels[k] != v {
+			t.Errorf("wanted label %v=%v. got %v", k, v, got.Labels[k])
+		}
+	}
+}
+
+func TestCompleteEntryWithoutLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	defer os.RemoveAll(dir)
+	logFile := filepath.Join(dir, "missing.log")
+	orgFile := filepath.Join(dir, "todo.org")
+	if err := ioutil.WriteFile(orgFile, []byte("* TODO fix the bug\n"), 0644); err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	c := &FileConfig{LogFile: &logFile, OrgFiles: []string{orgFile}}
+	now := time.Date(2020, time.November, 25, 10, 0, 0, 0, time.UTC)
+	if err := c.CompleteEntry("bug", now); err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if err := c.CompleteEntry("nothing", now); err == nil {
+		t.Errorf("wanted an error when nothing matches. got nil")
+	}
+}
@@ -0,0 +1,279 @@
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+// Source reads a log from one input, such as a record jar or an org file.
+type Source interface {
+	Read() (types.Log, error)
+}
+
+// Tidier is a Source backed by a file which can be tidied in place.
+type Tidier interface {
+	Source
+	Filename() string
+	// Tidy formats doc, rewriting label aliases to their canonical
+	// values when canonical is set.
+	Tidy(doc string, canonical bool) string
+}
+
+// SourceConfig is one input of the log. The type of source is Type when
+// set, otherwise the scheme of Path (e.g. "org:notes.txt") or its
+// extension (e.g. "notes.org"). Paths without either are record jars.
+type SourceConfig struct {
+	Path    string
+	Type    *string
+	Options map[string]string
+	// optional sources read as empty when their file doesn't exist.
+	optional bool
+}
+
+// SourceFactory makes a Source from its config. Options are up to the
+// source, except for "labels" (e.g. "src=work"), which every source
+// accepts and which adds labels to entries that don't already have them.
+type SourceFactory func(c *FileConfig, sc *SourceConfig) (Source, error)
+
+const (
+	logSourceType      = "log"
+	orgSourceType      = "org"
+	markdownSourceType = "md"
+
+	labelsOption = "labels"
+)
+
+var sourceFactories = map[string]SourceFactory{}
+
+// RegisterSource makes a type of source available by a scheme name like
+// "org" or an extension like ".org". It is meant to be called from init.
+func RegisterSource(key string, f SourceFactory) {
+	sourceFactories[key] = f
+}
+
+// SourceTypes lists the registered schemes and extensions.
+func SourceTypes() []string {
+	keys := []string{}
+	for k := range sourceFactories {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	RegisterSource(logSourceType, newLogSource)
+	RegisterSource(orgSourceType, newOrgSource)
+	RegisterSource(".org", newOrgSource)
+	RegisterSource(markdownSourceType, newMarkdownSource)
+	RegisterSource("markdown", newMarkdownSource)
+	RegisterSource(".md", newMarkdownSource)
+	RegisterSource(".markdown", newMarkdownSource)
+}
+
+// resolve returns the registry key and the file path of a source.
+func (sc *SourceConfig) resolve() (string, string) {
+	if sc.Type != nil {
+		return *sc.Type, sc.Path
+	}
+	// A one letter scheme is a Windows drive.
+	if i := strings.Index(sc.Path, ":"); i > 1 {
+		if _, ok := sourceFactories[sc.Path[:i]]; ok {
+			return sc.Path[:i], strings.TrimPrefix(sc.Path[i+1:], "//")
+		}
+	}
+	if ext := strings.ToLower(filepath.Ext(sc.Path)); ext != "" {
+		if _, ok := sourceFactories[ext]; ok {
+			return ext, sc.Path
+		}
+	}
+	return logSourceType, sc.Path
+}
+
+// LogPath returns the file path of a record jar source. It reports false
+// for any other type of source.
+func (sc *SourceConfig) LogPath() (string, bool) {
+	key, path := sc.resolve()
+	return path, key == logSourceType
+}
+
+// GetSources lists the log file, the org and Markdown files and then any
+// other configured sources. A file listed more than once is read only the
+// first time.
+func (c *FileConfig) GetSources() []*SourceConfig {
+	logType, orgType, markdownType := logSourceType, orgSourceType, markdownSourceType
+	// The log file is optional when entries are kept in other sources.
+	sources := []*SourceConfig{{Path: c.GetLogFile(), Type: &logType, optional: true}}
+	for _, f := range c.GetOrgFiles() {
+		sources = append(sources, &SourceConfig{Path: f, Type: &orgType})
+	}
+	for _, f := range c.GetMarkdownFiles() {
+		sources = append(sources, &SourceConfig{Path: f, Type: &markdownType})
+	}
+	if c != nil {
+		sources = append(sources, c.Sources...)
+	}
+	seen := map[string]bool{}
+	unique := []*SourceConfig{}
+	for _, sc := range sources {
+		_, path := sc.resolve()
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		unique = append(unique, sc)
+	}
+	return unique
+}
+
+// NewSource makes the Source described by sc.
+func (c *FileConfig) NewSource(sc *SourceConfig) (Source, error) {
+	key, path := sc.resolve()
+	f, ok := sourceFactories[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown source type %q for %v: want one of %v",
+			key, sc.Path, strings.Join(SourceTypes(), ", "))
+	}
+	resolved := &SourceConfig{
+		Path:     path,
+		Type:     &key,
+		Options:  sc.Options,
+		optional: sc.optional,
+	}
+	return f(c, resolved)
+}
+
+// ReadSource reads one source and applies the options common to all
+// sources.
+func (c *FileConfig) ReadSource(sc *SourceConfig) (types.Log, error) {
+	s, err := c.NewSource(sc)
+	if err != nil {
+		return nil, err
+	}
+	log, err := s.Read()
+	if err != nil {
+		return nil, err
+	}
+	_, path := sc.resolve()
+	for _, week := range log {
+		for _, entry := range append(append([]*types.Entry{}, week.Done...), week.Todo...) {
+			entry.Sources = []string{path}
+		}
+	}
+	if labels, ok := sc.Options[labelsOption]; ok {
+		extra, err := c.parseLabels(labels)
+		if err != nil {
+			return nil, fmt.Errorf("source %v: %v", sc.Path, err)
+		}
+		for _, week := range log {
+			for _, entry := range append(append([]*types.Entry{}, week.Done...), week.Todo...) {
+				for k, v := range extra {
+					if _, ok := entry.Labels[k]; !ok {
+						entry.Labels[k] = c.canonicalLabel(k, v)
+					}
+				}
+			}
+		}
+	}
+	return log, nil
+}
+
+func (c *FileConfig) readSources(sources []*SourceConfig) (types.Log, error) {
+	var allLogs types.Log
+	for _, sc := range sources {
+		log, err := c.ReadSource(sc)
+		if err != nil {
+			return nil, err
+		}
+		allLogs = mergeLogs(allLogs, log)
+	}
+	return allLogs, nil
+}
+
+type logSource struct {
+	c        *FileConfig
+	filename string
+	optional bool
+}
+
+func newLogSource(c *FileConfig, sc *SourceConfig) (Source, error) {
+	return &logSource{c: c, filename: sc.Path, optional: sc.optional}, nil
+}
+
+func (s *logSource) Read() (types.Log, error) {
+	bs, err := ioutil.ReadFile(s.filename)
+	if s.optional && os.IsNotExist(err) {
+		return types.Log{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	log, errs := s.c.parseLog(s.filename, string(bs))
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return log, nil
+}
+
+type orgSource struct {
+	c        *FileConfig
+	filename string
+}
+
+func newOrgSource(c *FileConfig, sc *SourceConfig) (Source, error) {
+	return &orgSource{c: c, filename: sc.Path}, nil
+}
+
+func (s *orgSource) Read() (types.Log, error) {
+	bs, err := ioutil.ReadFile(s.filename)
+	if err != nil {
+		return nil, err
+	}
+	return s.c.ParseOrg(string(bs))
+}
+
+func (s *orgSource) Filename() string {
+	return s.filename
+}
+
+func (s *orgSource) Tidy(doc string, canonical bool) string {
+	return s.c.TidyOrg(doc, canonical)
+}
+
+type markdownSource struct {
+	c        *FileConfig
+	filename string
+}
+
+func newMarkdownSource(c *FileConfig, sc *SourceConfig) (Source, error) {
+	return &markdownSource{c: c, filename: sc.Path}, nil
+}
+
+func (s *markdownSource) Read() (types.Log, error) {
+	bs, err := ioutil.ReadFile(s.filename)
+	if err != nil {
+		return nil, err
+	}
+	log, errs := s.c.parseMarkdown(s.filename, string(bs))
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return log, nil
+}
+
+func (s *markdownSource) Filename() string {
+	return s.filename
+}
+
+func (s *markdownSource) Tidy(doc string, canonical bool) string {
+	return s.c.TidyMarkdown(doc, canonical)
+}
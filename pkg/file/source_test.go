@@ -0,0 +1,130 @@
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+type fakeSource struct {
+	week string
+}
+
+func (s *fakeSource) Read() (types.Log, error) {
+	date, err := time.Parse("2006-01-02", s.week)
+	if err != nil {
+		return nil, err
+	}
+	return types.Log{{
+		Date:   date,
+		Header: map[string][]string{},
+		Done: []*types.Entry{{
+			Line:   "from fake",
+			Labels: map[string]string{"cat": "a"},
+		}},
+	}}, nil
+}
+
+func TestSourceResolve(t *testing.T) {
+	md := "md"
+	cases := []struct {
+		sc       *SourceConfig
+		wantKey  string
+		wantPath string
+	}{
+		{&SourceConfig{Path: "notes.org"}, ".org", "notes.org"},
+		{&SourceConfig{Path: "notes.MD"}, ".md", "notes.MD"},
+		{&SourceConfig{Path: "org:notes.txt"}, "org", "notes.txt"},
+		{&SourceConfig{Path: "md://notes.txt"}, "md", "notes.txt"},
+		{&SourceConfig{Path: "C:\\tf\\log"}, "log", "C:\\tf\\log"},
+		{&SourceConfig{Path: "log.org", Type: &md}, "md", "log.org"},
+		{&SourceConfig{Path: ".tf/log"}, "log", ".tf/log"},
+	}
+	for _, c := range cases {
+		key, path := c.sc.resolve()
+		if key != c.wantKey || path != c.wantPath {
+			t.Errorf("%v: wanted %v %v. got %v %v", c.sc.Path, c.wantKey, c.wantPath, key, path)
+		}
+	}
+}
+
+func TestRegisterSource(t *testing.T) {
+	RegisterSource("fake", func(c *FileConfig, sc *SourceConfig) (Source, error) {
+		return &fakeSource{week: sc.Options["week"]}, nil
+	})
+	defer delete(sourceFactories, "fake")
+	log, err := (*FileConfig)(nil).ReadSource(&SourceConfig{
+		Path: "fake:anything",
+		Options: map[string]string{
+			"week":   "2020-11-23",
+			"labels": "cat=b src=fake",
+		},
+	})
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if len(log) != 1 || len(log[0].Done) != 1 {
+		t.Fatalf("wanted 1 week with 1 entry. got %v", log)
+	}
+	labels := log[0].Done[0].Labels
+	if labels["cat"] != "a" || labels["src"] != "fake" {
+		t.Errorf("wanted cat=a src=fake. got %v", labels)
+	}
+	_, err = (*FileConfig)(nil).NewSource(&SourceConfig{Path: "bogus:x", Type: new(string)})
+	if err == nil {
+		t.Errorf("wanted an error for an unknown source type")
+	}
+}
+
+func TestReadWithoutLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	defer os.RemoveAll(dir)
+	logFile := filepath.Join(dir, "missing.log")
+	orgFile := filepath.Join(dir, "todo.org")
+	if err := ioutil.WriteFile(orgFile, []byte("* DONE fix the bug :cat@a:\n  CLOSED: [2020-11-25 Wed 10:00]\n"), 0644); err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	c := &FileConfig{LogFile: &logFile, OrgFiles: []string{orgFile}}
+	log, err := c.Read()
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if len(log) != 1 || len(log[0].Done) != 1 {
+		t.Fatalf("wanted 1 week with 1 entry. got %v", log)
+	}
+	c.Sources = []*SourceConfig{{Path: filepath.Join(dir, "missing-too.log")}}
+	if _, err := c.Read(); err == nil {
+		t.Errorf("wanted an error for a missing source. got nil")
+	}
+}
+
+func TestGetSourcesUnique(t *testing.T) {
+	logFile := "/home/me/.tf/log"
+	c := &FileConfig{
+		LogFile:  &logFile,
+		OrgFiles: []string{"notes.org"},
+		Sources: []*SourceConfig{
+			{Path: "/home/me/.tf/./log"},
+			{Path: "org:notes.org"},
+			{Path: "work.log"},
+		},
+	}
+	got := []string{}
+	for _, sc := range c.GetSources() {
+		path, ok := sc.LogPath()
+		got = append(got, fmt.Sprintf("%v %v", path, ok))
+	}
+	want := []string{"/home/me/.tf/log true", "notes.org false", "work.log true"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("wanted %v. got %v", want, got)
+	}
+}
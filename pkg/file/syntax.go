@@ -0,0 +1,132 @@
+package file
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+// Document is a lossless syntax tree of a record jar log. Printing it
+// gives back the text it was parsed from, byte for byte, so that tools
+// can change some lines and keep the rest as written.
+type Document struct {
+	Records []*Record
+}
+
+// Record is one week of a Document, without its "%%" separator. The
+// header ends at the first blank line, which starts the body. Date is
+// zero when the Date header is missing or invalid.
+type Record struct {
+	Date   time.Time
+	Header []*Node
+	Body   []*Node
+}
+
+type NodeKind int
+
+const (
+	// HeaderNode is a "Key: value" line.
+	HeaderNode NodeKind = iota
+	// FoldNode continues the header line before it.
+	FoldNode
+	BlankNode
+	// CommentNode is a "//" line in the body, which isn't an entry. See
+	// FileConfig.Comments.
+	CommentNode
+	// DayNode starts a day section, e.g. "--- Mon".
+	DayNode
+	// EntryNode is an entry line. Entry is nil when it can't be parsed.
+	// Its labels are as written, see CanonicalizeDocument.
+	EntryNode
+)
+
+// Node is one line of a Record.
+type Node struct {
+	Kind NodeKind
+	// Text is the line as written, without its newline.
+	Text    string
+	Key     string
+	Value   string
+	Weekday time.Weekday
+	Entry   *types.Entry
+	Done    bool
+}
+
+var headerLine = regexp.MustCompile(`^([^:\s]+):\s*(.*)$`)
+
+// isComment reports whether a body line is a comment rather than an
+// entry. Comments are off unless enabled in the config, since a log may
+// have entries starting with "//".
+func (c *FileConfig) isComment(line string) bool {
+	if c == nil || c.Comments == nil || !*c.Comments {
+		return false
+	}
+	return strings.HasPrefix(strings.TrimSpace(line), "//")
+}
+
+// ParseDocument parses a record jar into a syntax tree. It never fails:
+// lines which can't be parsed are kept as they are.
+func (c *FileConfig) ParseDocument(recordJar string) *Document {
+	doc := &Document{}
+	for _, record := range strings.Split(recordJar, "%%\n") {
+		doc.Records = append(doc.Records, c.parseRecord(record))
+	}
+	return doc
+}
+
+func (c *FileConfig) parseRecord(record string) *Record {
+	r := &Record{}
+	inHeader := true
+	for _, line := range strings.Split(record, "\n") {
+		n := &Node{Text: line}
+		switch {
+		case strings.TrimSpace(line) == "":
+			n.Kind = BlankNode
+			inHeader = false
+		case inHeader && (line[0] == ' ' || line[0] == '\t'):
+			n.Kind = FoldNode
+		case inHeader:
+			n.Kind = HeaderNode
+			if m := headerLine.FindStringSubmatch(line); m != nil {
+				n.Key, n.Value = m[1], m[2]
+			}
+			if n.Key == "Date" {
+				r.Date, _ = c.parseDate(strings.TrimSpace(n.Value))
+			}
+		case c.isComment(line):
+			n.Kind = CommentNode
+		default:
+			if d, ok := ParseDayMarker(c.dewhite(line)); ok {
+				n.Kind = DayNode
+				n.Weekday = d
+				break
+			}
+			n.Kind = EntryNode
+			n.Entry, n.Done, _ = c.parseEntry(line)
+		}
+		if inHeader {
+			r.Header = append(r.Header, n)
+		} else {
+			r.Body = append(r.Body, n)
+		}
+	}
+	return r
+}
+
+func (r *Record) String() string {
+	lines := []string{}
+	for _, n := range append(append([]*Node{}, r.Header...), r.Body...) {
+		lines = append(lines, n.Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (d *Document) String() string {
+	records := []string{}
+	for _, r := range d.Records {
+		records = append(records, r.String())
+	}
+	return strings.Join(records, "%%\n")
+}
@@ -0,0 +1,157 @@
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+const (
+	carriedLabel = "carried"
+)
+
+func (c *FileConfig) carryHeaders() []string {
+	if c == nil {
+		return nil
+	}
+	return c.CarryHeaders
+}
+
+func (c *FileConfig) carryCounter() bool {
+	if c == nil || c.CarryCounter == nil {
+		return false
+	}
+	return *c.CarryCounter
+}
+
+func (c *FileConfig) moveTodos() bool {
+	if c == nil || c.MoveTodos == nil {
+		return false
+	}
+	return *c.MoveTodos
+}
+
+// NewWeek returns a week dated to the start of the week containing now.
+// Unfinished TODO entries and the configured headers are carried over
+// from the latest week in log.
+func (c *FileConfig) NewWeek(log types.Log, now time.Time) (*types.Week, error) {
+	date := c.WeekOf(now)
+	week := &types.Week{
+		Date:   date,
+		Header: map[string][]string{},
+		Done:   []*types.Entry{},
+		Todo:   []*types.Entry{},
+	}
+	latest := latestWeek(log)
+	if latest == nil {
+		c.fillHeaders(week)
+		return week, nil
+	}
+	if !latest.Date.Before(date) {
+		return nil, fmt.Errorf("a record for the week of %v already exists", date.Format(dateFormat))
+	}
+	for _, k := range c.carryHeaders() {
+		if vs, ok := latest.Header[k]; ok {
+			week.Header[k] = append([]string{}, vs...)
+		}
+	}
+	c.fillHeaders(week)
+	for _, entry := range latest.Todo {
+		labels := map[string]string{}
+		for k, v := range entry.Labels {
+			labels[k] = v
+		}
+		if c.carryCounter() {
+			n, _ := strconv.Atoi(labels[carriedLabel])
+			labels[carriedLabel] = strconv.Itoa(n + 1)
+		}
+		week.Todo = append(week.Todo, &types.Entry{
+			Line:   entry.Line,
+			Labels: labels,
+		})
+	}
+	return week, nil
+}
+
+// InsertWeek adds a new week record to the log file. When moving TODOs,
+// the unfinished entries are removed from the latest existing record.
+func (c *FileConfig) InsertWeek(record string) error {
+	bs, err := ioutil.ReadFile(c.GetLogFile())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	recordJar := string(bs)
+	if c.moveTodos() && strings.TrimSpace(recordJar) != "" {
+		recordJar, err = c.removeLatestTodos(recordJar)
+		if err != nil {
+			return err
+		}
+	}
+	recordJar, err = c.insertRecord(recordJar, record)
+	if err != nil {
+		return err
+	}
+	return c.WriteLog(recordJar)
+}
+
+func (c *FileConfig) removeLatestTodos(recordJar string) (string, error) {
+	records := strings.Split(recordJar, "%%\n")
+	latest := -1
+	var latestDate time.Time
+	for i, record := range records {
+		w, err := c.ParseWeek(record)
+		if err != nil {
+			return "", err
+		}
+		if latest == -1 || w.Date.After(latestDate) {
+			latest, latestDate = i, w.Date
+		}
+	}
+	lines := strings.Split(records[latest], "\n")
+	kept := []string{}
+	inBody := false
+	for _, line := range lines {
+		if !inBody {
+			inBody = strings.TrimSpace(line) == ""
+			kept = append(kept, line)
+			continue
+		}
+		if c.dewhite(line) != "" {
+			_, done, err := c.ParseEntry(line)
+			if err != nil {
+				return "", err
+			}
+			if !done {
+				continue
+			}
+		}
+		kept = append(kept, line)
+	}
+	records[latest] = strings.Join(kept, "\n")
+	return strings.Join(records, "%%\n"), nil
+}
+
+// fillHeaders sets the default of every header in the schema which the
+// week doesn't have yet.
+func (c *FileConfig) fillHeaders(week *types.Week) {
+	for _, f := range c.GetHeaderSchema() {
+		if _, ok := week.Header[f.Key]; !ok && f.Default != nil {
+			week.Header[f.Key] = []string{*f.Default}
+		}
+	}
+}
+
+func latestWeek(log types.Log) *types.Week {
+	var latest *types.Week
+	for _, w := range log {
+		if latest == nil || w.Date.After(latest.Date) {
+			latest = w
+		}
+	}
+	return latest
+}
@@ -0,0 +1,87 @@
+package file
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewWeek(t *testing.T) {
+	now := time.Date(2020, time.December, 2, 0, 0, 0, 0, time.UTC)
+	yes := true
+	cases := []struct {
+		name    string
+		config  *FileConfig
+		record  string
+		want    []string
+		wantErr bool
+	}{{
+		name:   "carried todo",
+		record: "Date: Nov 23 2020\n\n[x] done thing ## cat=a\n[ ] open thing ## cat=b\n",
+		want:   []string{"open thing cat=b"},
+	}, {
+		name:   "hash todo",
+		record: "Date: Nov 23 2020\n\n# open thing ## cat=b\nother thing\n",
+		want:   []string{"open thing cat=b"},
+	}, {
+		name:   "counter",
+		config: &FileConfig{CarryCounter: &yes},
+		record: "Date: Nov 23 2020\n\n[ ] new ## cat=a\n[ ] old ## carried=2 cat=b\n",
+		want:   []string{"new carried=1 cat=a", "old carried=3 cat=b"},
+	}, {
+		name:    "existing week",
+		record:  "Date: Nov 30 2020\n\n[ ] open thing\n",
+		wantErr: true,
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			log, err := c.config.ParseLog(c.record)
+			if err != nil {
+				t.Fatalf("wanted no error. got %v", err)
+			}
+			week, err := c.config.NewWeek(log, now)
+			if c.wantErr {
+				if err == nil {
+					t.Errorf("wanted an error. got %v", week)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("wanted no error. got %v", err)
+			}
+			if got := week.Date.Format(dateFormat); got != "Nov 30 2020" {
+				t.Errorf("wanted the week of Nov 30 2020. got %v", got)
+			}
+			got := []string{}
+			for _, e := range week.Todo {
+				got = append(got, e.Line+" "+sprintTestLabels(e.Labels))
+			}
+			if strings.Join(got, "\n") != strings.Join(c.want, "\n") {
+				t.Errorf("wanted todos %q. got %q", c.want, got)
+			}
+		})
+	}
+}
+
+func TestRemoveLatestTodos(t *testing.T) {
+	recordJar := "Date: Nov 16 2020\n\n[ ] older\n%%\nDate: Nov 23 2020\n\n[x] done ## cat=a\n[ ] open ## cat=b\n# hashed\n"
+	want := "Date: Nov 16 2020\n\n[ ] older\n%%\nDate: Nov 23 2020\n\n[x] done ## cat=a\n"
+	got, err := (*FileConfig)(nil).removeLatestTodos(recordJar)
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if got != want {
+		t.Errorf("wanted %q. got %q", want, got)
+	}
+}
+
+// sprintTestLabels formats labels sorted by key, e.g. "a=1 b=2".
+func sprintTestLabels(labels map[string]string) string {
+	pairs := []string{}
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
@@ -0,0 +1,71 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	backupSuffix = ".bak"
+)
+
+// WriteLog atomically replaces the log file with recordJar. The previous
+// contents are kept in a backup file next to the log.
+func (c *FileConfig) WriteLog(recordJar string) error {
+	return writeFile(c.GetLogFile(), []byte(recordJar))
+}
+
+// WriteFile atomically replaces a file such as an org or Markdown log with
+// doc, keeping the previous contents in a backup file.
+func WriteFile(filename, doc string) error {
+	return writeFile(filename, []byte(doc))
+}
+
+// writeFile replaces the file which filename points to, so that a
+// symlinked log stays a symlink.
+func writeFile(filename string, bs []byte) error {
+	mode := os.FileMode(0644)
+	info, err := os.Stat(filename)
+	switch {
+	case err == nil:
+		filename, err = filepath.EvalSymlinks(filename)
+		if err != nil {
+			return err
+		}
+		mode = info.Mode()
+		if err := backup(filename, mode); err != nil {
+			return err
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(bs); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+func backup(filename string, mode os.FileMode) error {
+	bs, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename+backupSuffix, bs, mode)
+}
@@ -0,0 +1,50 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileSymlink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tf")
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	defer os.RemoveAll(dir)
+	target := filepath.Join(dir, "target")
+	link := filepath.Join(dir, "log")
+	if err := ioutil.WriteFile(target, []byte("old"), 0600); err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+
+	if err := WriteFile(link, "new"); err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("wanted %v to stay a symlink. got mode %v", link, info.Mode())
+	}
+	bs, err := ioutil.ReadFile(target)
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if string(bs) != "new" {
+		t.Errorf("wanted %q. got %q", "new", string(bs))
+	}
+	bs, err = ioutil.ReadFile(target + backupSuffix)
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if string(bs) != "old" {
+		t.Errorf("wanted backup %q. got %q", "old", string(bs))
+	}
+}
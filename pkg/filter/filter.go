@@ -0,0 +1,182 @@
+package filter
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+// FilterConfig limits a log to a date range and to entries matching
+// every label predicate. Predicates are one of:
+//
+//	k=v   a value of label k equals v or starts with the path v/ (v can
+//	      be a glob)
+//	k!=v  label k is missing or none of its values equal v
+//	k     label k exists
+//	!k    label k is missing
+//
+// Since and Until are dates like 2006-01-02 or a number of days or
+// weeks before now like 30d or 8w.
+type FilterConfig struct {
+	Since *string
+	Until *string
+	Where []string
+}
+
+type predicate struct {
+	key    string
+	value  string
+	op     string
+	negate bool
+}
+
+var relativeDate = regexp.MustCompile(`^(\d+)([dw])$`)
+
+func (c *FilterConfig) IsSet() bool {
+	return c != nil && (c.Since != nil || c.Until != nil || len(c.Where) > 0)
+}
+
+// Filter returns the weeks of log within the date range, with only the
+// entries matching every predicate.
+func (c *FilterConfig) Filter(log types.Log, now time.Time) (types.Log, error) {
+	if !c.IsSet() {
+		return log, nil
+	}
+	since, err := parseDate(c.Since, now)
+	if err != nil {
+		return nil, err
+	}
+	until, err := parseDate(c.Until, now)
+	if err != nil {
+		return nil, err
+	}
+	predicates, err := parsePredicates(c.Where)
+	if err != nil {
+		return nil, err
+	}
+	filtered := types.Log{}
+	for _, week := range log {
+		if !since.IsZero() && week.Date.Before(since) {
+			continue
+		}
+		if !until.IsZero() && week.Date.After(until) {
+			continue
+		}
+		w := *week
+		w.Done = filterEntries(week.Done, predicates)
+		w.Todo = filterEntries(week.Todo, predicates)
+		filtered = append(filtered, &w)
+	}
+	return filtered, nil
+}
+
+func parseDate(s *string, now time.Time) (time.Time, error) {
+	if s == nil || *s == "" {
+		return time.Time{}, nil
+	}
+	if m := relativeDate.FindStringSubmatch(*s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if m[2] == "w" {
+			n *= 7
+		}
+		year, month, day := now.Date()
+		return time.Date(year, month, day-n, 0, 0, 0, 0, time.UTC), nil
+	}
+	t, err := time.Parse("2006-01-02", *s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: want 2006-01-02, 30d or 8w", *s)
+	}
+	return t, nil
+}
+
+func parsePredicates(where []string) ([]*predicate, error) {
+	predicates := []*predicate{}
+	for _, w := range where {
+		p := &predicate{}
+		switch {
+		case strings.Contains(w, "!="):
+			parts := strings.SplitN(w, "!=", 2)
+			p.key, p.value, p.op, p.negate = parts[0], parts[1], "=", true
+		case strings.Contains(w, "="):
+			parts := strings.SplitN(w, "=", 2)
+			p.key, p.value, p.op = parts[0], parts[1], "="
+		case strings.HasPrefix(w, "!"):
+			p.key, p.negate = w[1:], true
+		default:
+			p.key = w
+		}
+		if p.key == "" {
+			return nil, fmt.Errorf("invalid label predicate %q", w)
+		}
+		if _, err := path.Match(p.value, ""); err != nil {
+			return nil, fmt.Errorf("invalid label predicate %q: %v", w, err)
+		}
+		predicates = append(predicates, p)
+	}
+	return predicates, nil
+}
+
+func (p *predicate) match(entry *types.Entry) bool {
+	v, ok := entry.Labels[p.key]
+	matched := ok
+	if ok && p.op == "=" {
+		matched = false
+		for _, value := range types.LabelValues(v) {
+			if matchPath(p.value, value) {
+				matched = true
+			}
+		}
+	}
+	return matched != p.negate
+}
+
+// matchPath reports whether pattern matches a label value or one of its
+// path prefixes, so that "primary" matches "primary/thing-one".
+func matchPath(pattern, value string) bool {
+	segments := strings.Split(value, "/")
+	for i := len(segments); i > 0; i-- {
+		if ok, _ := path.Match(pattern, strings.Join(segments[:i], "/")); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Matcher returns a function reporting whether an entry matches every
+// label predicate in where.
+func Matcher(where []string) (func(*types.Entry) bool, error) {
+	predicates, err := parsePredicates(where)
+	if err != nil {
+		return nil, err
+	}
+	return func(entry *types.Entry) bool {
+		for _, p := range predicates {
+			if !p.match(entry) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func filterEntries(entries []*types.Entry, predicates []*predicate) []*types.Entry {
+	filtered := []*types.Entry{}
+	for _, entry := range entries {
+		keep := true
+		for _, p := range predicates {
+			if !p.match(entry) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,102 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+func TestFilter(t *testing.T) {
+	now := time.Date(2020, time.December, 1, 0, 0, 0, 0, time.UTC)
+	log := types.Log{{
+		Date: time.Date(2020, time.November, 23, 0, 0, 0, 0, time.UTC),
+		Done: []*types.Entry{
+			{Line: "a", Labels: map[string]string{"cat": "customer"}},
+			{Line: "b", Labels: map[string]string{"cat": "customer", "sub": "ops"}},
+			{Line: "c", Labels: map[string]string{"cat": "community"}},
+			{Line: "d", Labels: map[string]string{}},
+			{Line: "f", Labels: map[string]string{"cat": "customer/acme/billing"}},
+		},
+	}, {
+		Date: time.Date(2020, time.September, 7, 0, 0, 0, 0, time.UTC),
+		Done: []*types.Entry{
+			{Line: "e", Labels: map[string]string{"cat": "customer"}},
+			{Line: "g", Labels: map[string]string{"cat": "community,primary"}},
+		},
+	}}
+	str := func(s string) *string { return &s }
+	cases := []struct {
+		name   string
+		config *FilterConfig
+		want   [][]string
+	}{{
+		name:   "no filter",
+		config: nil,
+		want:   [][]string{{"a", "b", "c", "d", "f"}, {"e", "g"}},
+	}, {
+		name:   "since weeks ago",
+		config: &FilterConfig{Since: str("8w")},
+		want:   [][]string{{"a", "b", "c", "d", "f"}},
+	}, {
+		name:   "until date",
+		config: &FilterConfig{Until: str("2020-10-01")},
+		want:   [][]string{{"e", "g"}},
+	}, {
+		name:   "equals and missing",
+		config: &FilterConfig{Where: []string{"cat=customer", "!sub"}},
+		want:   [][]string{{"a", "f"}, {"e"}},
+	}, {
+		name:   "glob",
+		config: &FilterConfig{Where: []string{"cat=c*m*"}},
+		want:   [][]string{{"a", "b", "c", "f"}, {"e", "g"}},
+	}, {
+		name:   "not equals and exists",
+		config: &FilterConfig{Where: []string{"cat!=customer", "cat"}},
+		want:   [][]string{{"c"}, {"g"}},
+	}, {
+		name:   "path prefix",
+		config: &FilterConfig{Where: []string{"cat=customer/acme"}},
+		want:   [][]string{{"f"}, {}},
+	}, {
+		name:   "path glob",
+		config: &FilterConfig{Where: []string{"cat=*/acme"}},
+		want:   [][]string{{"f"}, {}},
+	}, {
+		name:   "list value",
+		config: &FilterConfig{Where: []string{"cat=primary"}},
+		want:   [][]string{{}, {"g"}},
+	}, {
+		name:   "not in list",
+		config: &FilterConfig{Where: []string{"cat!=primary"}},
+		want:   [][]string{{"a", "b", "c", "d", "f"}, {"e"}},
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := c.config.Filter(log, now)
+			if err != nil {
+				t.Fatalf("wanted no error. got %v", err)
+			}
+			if len(got) != len(c.want) {
+				t.Fatalf("wanted %v weeks. got %v", len(c.want), len(got))
+			}
+			for i, week := range got {
+				lines := []string{}
+				for _, e := range week.Done {
+					lines = append(lines, e.Line)
+				}
+				if len(lines) != len(c.want[i]) {
+					t.Errorf("[%v] wanted %v. got %v", i, c.want[i], lines)
+					continue
+				}
+				for j := range lines {
+					if lines[j] != c.want[i][j] {
+						t.Errorf("[%v] wanted %v. got %v", i, c.want[i], lines)
+						break
+					}
+				}
+			}
+		})
+	}
+}
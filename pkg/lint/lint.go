@@ -0,0 +1,166 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/josephburnett/time-flies/pkg/budget"
+	"github.com/josephburnett/time-flies/pkg/file"
+	"github.com/josephburnett/time-flies/pkg/suggest"
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+var (
+	defaultTimeLabels = []string{
+		"f",
+		"t",
+		"carried",
+	}
+)
+
+type LintConfig struct {
+	KnownLabels []string
+}
+
+// Problem is a semantic problem with a log entry or week.
+type Problem struct {
+	Pos types.Position
+	Msg string
+}
+
+func (p *Problem) String() string {
+	return fmt.Sprintf("%v: %v", p.Pos, p.Msg)
+}
+
+// knownLabels returns the configured label keys or, by default, the label
+// grouping and time labels.
+func (c *LintConfig) knownLabels(bc *budget.BudgetConfig) map[string]bool {
+	keys := map[string]bool{}
+	if c != nil && len(c.KnownLabels) > 0 {
+		for _, k := range c.KnownLabels {
+			keys[k] = true
+		}
+		return keys
+	}
+	for _, k := range bc.GetLabelGrouping() {
+		keys[k] = true
+	}
+	for _, k := range defaultTimeLabels {
+		keys[k] = true
+	}
+	return keys
+}
+
+// Lint returns the parse errors and the problems found in log, ordered by
+// position. Week headers are checked against the header schema of fc.
+// When model is set, done entries missing a suggested label are reported
+// along with the most likely value.
+func (c *LintConfig) Lint(log types.Log, errs file.ParseErrors, bc *budget.BudgetConfig, fc *file.FileConfig, model *suggest.Model) []*Problem {
+	problems := []*Problem{}
+	add := func(pos types.Position, format string, a ...interface{}) {
+		problems = append(problems, &Problem{
+			Pos: pos,
+			Msg: fmt.Sprintf(format, a...),
+		})
+	}
+	for _, e := range errs {
+		add(e.Pos, "%v", e.Msg)
+	}
+	known := c.knownLabels(bc)
+	category := bc.GetLabelGrouping()[0]
+	keys := []string{category}
+	for _, k := range model.Keys() {
+		if k != category {
+			keys = append(keys, k)
+		}
+	}
+	weeks := map[string]*types.Week{}
+	for _, week := range log {
+		key := week.Date.Format("2006-01-02")
+		if first, ok := weeks[key]; ok {
+			add(week.Pos, "duplicate week %v (first at %v)", week.Date.Format("Jan 02 2006"), first.Pos)
+		} else {
+			weeks[key] = week
+		}
+		for _, msg := range lintHeaders(week, fc) {
+			add(week.Pos, "%v", msg)
+		}
+		for _, entry := range append(append([]*types.Entry{}, week.Done...), week.Todo...) {
+			if err := bc.ValidateEntry(entry); err != nil {
+				add(entry.Pos, "%v", err)
+			}
+			for _, k := range sortedKeys(entry.Labels) {
+				if !known[k] {
+					add(entry.Pos, "unknown label %q", k)
+				}
+			}
+		}
+		for _, entry := range week.Done {
+			for _, k := range keys {
+				if _, ok := entry.Labels[k]; ok {
+					continue
+				}
+				// Only the category is required, other labels are
+				// reported when there is a suggestion for them.
+				if s := model.Best(entry.Line, k); s != nil {
+					add(entry.Pos, "missing %q label (suggest %v=%v, %.0f%%)", k, k, s.Value, s.Confidence*100)
+				} else if k == category {
+					add(entry.Pos, "missing %q label", k)
+				}
+			}
+		}
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		a, b := problems[i].Pos, problems[j].Pos
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return problems
+}
+
+// lintHeaders checks the headers of a week against the header schema.
+// Without a schema any header is allowed.
+func lintHeaders(week *types.Week, fc *file.FileConfig) []string {
+	schema := fc.GetHeaderSchema()
+	if len(schema) == 0 {
+		return nil
+	}
+	msgs := []string{}
+	keys := []string{}
+	for k := range week.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		f := fc.HeaderField(k)
+		if f == nil {
+			msgs = append(msgs, fmt.Sprintf("unknown header %q", k))
+			continue
+		}
+		for _, v := range week.Header[k] {
+			if err := fc.ValidateHeader(f, v); err != nil {
+				msgs = append(msgs, err.Error())
+			}
+		}
+	}
+	for _, f := range schema {
+		if _, ok := week.Header[f.Key]; !ok && f.IsRequired() {
+			msgs = append(msgs, fmt.Sprintf("missing required %q header", f.Key))
+		}
+	}
+	return msgs
+}
+
+func sortedKeys(labels map[string]string) []string {
+	keys := []string{}
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
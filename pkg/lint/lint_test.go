@@ -0,0 +1,77 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/josephburnett/time-flies/pkg/budget"
+	"github.com/josephburnett/time-flies/pkg/file"
+	"github.com/josephburnett/time-flies/pkg/suggest"
+)
+
+func TestLint(t *testing.T) {
+	recordJar := `Date: Nov 23 2020
+
+fix bug http://bug/1 ## cat=customer sub=bugs
+fix bug http://bug/2 ## cat=customer sub=bugs
+write blog post      ## cat=community
+triage http://bug/3
+blog about it        ## cat=community
+something else       ## cat=primary t=1x
+%%
+Date: Nov 23 2020
+
+a week again ## cat=primary bogus=1
+`
+	fc := &file.FileConfig{}
+	log, errs := fc.ParseLog(recordJar)
+	if errs != nil {
+		t.Fatalf("wanted no error. got %v", errs)
+	}
+	model := (&suggest.SuggestConfig{}).Train(log, []string{"cat"})
+	sure := 0.9
+	unsure := (&suggest.SuggestConfig{MinConfidence: &sure}).Train(log, []string{"cat"})
+	cases := []struct {
+		name  string
+		model *suggest.Model
+		want  []string
+	}{{
+		name: "without a model",
+		want: []string{
+			`log:6:1: missing "cat" label`,
+			`log:8:1: malformed 't': time: unknown unit "x" in duration "1x"`,
+			`log:10: duplicate week Nov 23 2020 (first at log:1)`,
+			`log:12:1: unknown label "bogus"`,
+		},
+	}, {
+		name:  "with a model",
+		model: model,
+		want: []string{
+			`log:6:1: missing "cat" label (suggest cat=customer, 59%)`,
+			`log:8:1: malformed 't': time: unknown unit "x" in duration "1x"`,
+			`log:10: duplicate week Nov 23 2020 (first at log:1)`,
+			`log:12:1: unknown label "bogus"`,
+		},
+	}, {
+		name:  "without a confident suggestion",
+		model: unsure,
+		want: []string{
+			`log:6:1: missing "cat" label`,
+			`log:8:1: malformed 't': time: unknown unit "x" in duration "1x"`,
+			`log:10: duplicate week Nov 23 2020 (first at log:1)`,
+			`log:12:1: unknown label "bogus"`,
+		},
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			problems := (*LintConfig)(nil).Lint(log, nil, (*budget.BudgetConfig)(nil), fc, c.model)
+			got := []string{}
+			for _, p := range problems {
+				got = append(got, p.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(c.want, "\n") {
+				t.Errorf("wanted:\n%v\ngot:\n%v", strings.Join(c.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
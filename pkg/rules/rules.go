@@ -0,0 +1,139 @@
+package rules
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+
+	"github.com/josephburnett/time-flies/pkg/filter"
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+// RulesConfig fills in labels which can be derived from an entry.
+type RulesConfig struct {
+	LabelRules []*LabelRule
+}
+
+// LabelRule sets labels on entries matching all of its conditions. Line
+// is a regular expression on the line, Host is a URL host in the line
+// (a glob like "*.example.com") and Where are label predicates as used by
+// filters. Set only fills in labels which are missing, so earlier rules
+// take precedence over later ones and labels written in the log always
+// win.
+type LabelRule struct {
+	Name  *string
+	Line  *string
+	Host  *string
+	Where []string
+	Set   map[string]string
+}
+
+var urlHost = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://([^/\s?#]+)`)
+
+type rule struct {
+	name  string
+	line  *regexp.Regexp
+	host  string
+	where func(*types.Entry) bool
+	set   map[string]string
+}
+
+func (c *RulesConfig) compile() ([]*rule, error) {
+	if c == nil {
+		return nil, nil
+	}
+	rules := []*rule{}
+	for i, lr := range c.LabelRules {
+		r := &rule{
+			name: fmt.Sprintf("rule %v", i+1),
+			set:  lr.Set,
+		}
+		if lr.Name != nil {
+			r.name = *lr.Name
+		}
+		if lr.Line != nil {
+			re, err := regexp.Compile(*lr.Line)
+			if err != nil {
+				return nil, fmt.Errorf("%v: invalid line pattern: %v", r.name, err)
+			}
+			r.line = re
+		}
+		if lr.Host != nil {
+			if _, err := path.Match(*lr.Host, ""); err != nil {
+				return nil, fmt.Errorf("%v: invalid host pattern: %v", r.name, err)
+			}
+			r.host = *lr.Host
+		}
+		where, err := filter.Matcher(lr.Where)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", r.name, err)
+		}
+		r.where = where
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func (r *rule) matches(entry *types.Entry) bool {
+	if r.line != nil && !r.line.MatchString(entry.Line) {
+		return false
+	}
+	if r.host != "" {
+		found := false
+		for _, m := range urlHost.FindAllStringSubmatch(entry.Line, -1) {
+			if ok, _ := path.Match(r.host, m[1]); ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return r.where(entry)
+}
+
+// apply fills in the missing labels of entry from each matching rule in
+// order, recording which rule set each label.
+func (r *rule) apply(entry *types.Entry) {
+	if !r.matches(entry) {
+		return
+	}
+	keys := []string{}
+	for k := range r.set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, ok := entry.Labels[k]; ok {
+			continue
+		}
+		entry.Labels[k] = r.set[k]
+		if entry.Derived == nil {
+			entry.Derived = map[string]string{}
+		}
+		entry.Derived[k] = r.name
+	}
+}
+
+// Apply fills in derived labels on every entry of log.
+func (c *RulesConfig) Apply(log types.Log) error {
+	rules, err := c.compile()
+	if err != nil {
+		return err
+	}
+	for _, week := range log {
+		for _, entry := range append(append([]*types.Entry{}, week.Done...), week.Todo...) {
+			for _, r := range rules {
+				r.apply(entry)
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyEntry fills in derived labels on one entry.
+func (c *RulesConfig) ApplyEntry(entry *types.Entry) error {
+	return c.Apply(types.Log{{Done: []*types.Entry{entry}}})
+}
@@ -0,0 +1,53 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+func TestApply(t *testing.T) {
+	name := "customer-bugs"
+	host, line := "bug", "(?i)eng review"
+	c := &RulesConfig{
+		LabelRules: []*LabelRule{
+			{Name: &name, Host: &host, Set: map[string]string{"cat": "customer"}},
+			{Line: &line, Set: map[string]string{"cat": "primary", "sub": "review"}},
+			{Where: []string{"cat=customer", "!sub"}, Set: map[string]string{"sub": "bugs"}},
+		},
+	}
+	cases := []struct {
+		line        string
+		labels      map[string]string
+		wantLabels  map[string]string
+		wantDerived map[string]string
+	}{{
+		line:        "fixed http://bug/123",
+		labels:      map[string]string{},
+		wantLabels:  map[string]string{"cat": "customer", "sub": "bugs"},
+		wantDerived: map[string]string{"cat": "customer-bugs", "sub": "rule 3"},
+	}, {
+		line:        "Eng review of design",
+		labels:      map[string]string{"sub": "design"},
+		wantLabels:  map[string]string{"cat": "primary", "sub": "design"},
+		wantDerived: map[string]string{"cat": "rule 2"},
+	}, {
+		line:        "see http://debug.example.com/ and eng review",
+		labels:      map[string]string{"cat": "x"},
+		wantLabels:  map[string]string{"cat": "x", "sub": "review"},
+		wantDerived: map[string]string{"sub": "rule 2"},
+	}}
+	for _, tc := range cases {
+		entry := &types.Entry{Line: tc.line, Labels: tc.labels}
+		if err := c.ApplyEntry(entry); err != nil {
+			t.Fatalf("wanted no error. got %v", err)
+		}
+		if !reflect.DeepEqual(entry.Labels, tc.wantLabels) {
+			t.Errorf("%v: wanted labels %v. got %v", tc.line, tc.wantLabels, entry.Labels)
+		}
+		if !reflect.DeepEqual(entry.Derived, tc.wantDerived) {
+			t.Errorf("%v: wanted derived %v. got %v", tc.line, tc.wantDerived, entry.Derived)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package suggest
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+const (
+	defaultMinConfidence = 0.5
+	hostToken            = "host:"
+)
+
+// SuggestConfig configures label suggestions learned from the log.
+type SuggestConfig struct {
+	// SuggestLabels are the label keys to suggest values for. They
+	// default to the label grouping.
+	SuggestLabels []string
+	// MinConfidence is the lowest confidence of a suggestion made by
+	// lint. It defaults to 0.5.
+	MinConfidence *float64
+}
+
+func (c *SuggestConfig) GetSuggestLabels(grouping []string) []string {
+	if c == nil || len(c.SuggestLabels) == 0 {
+		return grouping
+	}
+	return c.SuggestLabels
+}
+
+func (c *SuggestConfig) GetMinConfidence() float64 {
+	if c == nil || c.MinConfidence == nil {
+		return defaultMinConfidence
+	}
+	return *c.MinConfidence
+}
+
+// Suggestion is a label value for an entry with the probability of it
+// being right.
+type Suggestion struct {
+	Label      string
+	Value      string
+	Confidence float64
+}
+
+// Model is a naive Bayes classifier over the tokens and URL hosts of
+// entry lines, with one class per label value.
+type Model struct {
+	classifiers map[string]*classifier
+	keys        []string
+	min         float64
+}
+
+type classifier struct {
+	entries int
+	// docs counts the entries of each value.
+	docs map[string]int
+	// tokens counts the tokens of the entries of each value.
+	tokens map[string]map[string]int
+	total  map[string]int
+	vocab  map[string]bool
+}
+
+var (
+	url  = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://([^/\s?#]+)\S*`)
+	word = regexp.MustCompile(`[\pL\pN]{2,}`)
+)
+
+// tokens returns the lowercase words of a line, with each URL replaced by
+// a token for its host.
+func tokens(line string) []string {
+	ts := []string{}
+	for _, m := range url.FindAllStringSubmatch(line, -1) {
+		ts = append(ts, hostToken+strings.ToLower(m[1]))
+	}
+	line = url.ReplaceAllString(line, " ")
+	for _, w := range word.FindAllString(strings.ToLower(line), -1) {
+		ts = append(ts, w)
+	}
+	return ts
+}
+
+// Train learns the values of the suggested label keys from the done
+// entries of log which have that label written in the log. Labels derived
+// by rules are left out.
+func (c *SuggestConfig) Train(log types.Log, grouping []string) *Model {
+	return train(log, c.GetSuggestLabels(grouping), c.GetMinConfidence())
+}
+
+func train(log types.Log, keys []string, min float64) *Model {
+	m := &Model{
+		classifiers: map[string]*classifier{},
+		keys:        keys,
+		min:         min,
+	}
+	for _, key := range keys {
+		c := &classifier{
+			docs:   map[string]int{},
+			tokens: map[string]map[string]int{},
+			total:  map[string]int{},
+			vocab:  map[string]bool{},
+		}
+		for _, week := range log {
+			for _, entry := range week.Done {
+				value, ok := entry.Labels[key]
+				if _, derived := entry.Derived[key]; !ok || derived {
+					continue
+				}
+				c.entries++
+				// Each value of a list is learned from the entry, as
+				// budget splits the entry between them.
+				for _, value := range types.LabelValues(value) {
+					c.docs[value]++
+					if c.tokens[value] == nil {
+						c.tokens[value] = map[string]int{}
+					}
+					for _, t := range tokens(entry.Line) {
+						c.tokens[value][t]++
+						c.total[value]++
+						c.vocab[t] = true
+					}
+				}
+			}
+		}
+		m.classifiers[key] = c
+	}
+	return m
+}
+
+// Suggest returns the values of key for line, most likely first.
+func (m *Model) Suggest(line, key string) []*Suggestion {
+	if m == nil {
+		return nil
+	}
+	c, ok := m.classifiers[key]
+	if !ok || c.entries == 0 {
+		return nil
+	}
+	ts := tokens(line)
+	scores := map[string]float64{}
+	best := math.Inf(-1)
+	for value, docs := range c.docs {
+		score := math.Log(float64(docs) / float64(c.entries))
+		for _, t := range ts {
+			if !c.vocab[t] {
+				continue
+			}
+			score += math.Log(float64(c.tokens[value][t]+1) / float64(c.total[value]+len(c.vocab)))
+		}
+		scores[value] = score
+		best = math.Max(best, score)
+	}
+	var sum float64
+	for _, score := range scores {
+		sum += math.Exp(score - best)
+	}
+	suggestions := []*Suggestion{}
+	for value, score := range scores {
+		suggestions = append(suggestions, &Suggestion{
+			Label:      key,
+			Value:      value,
+			Confidence: math.Exp(score-best) / sum,
+		})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Confidence != suggestions[j].Confidence {
+			return suggestions[i].Confidence > suggestions[j].Confidence
+		}
+		return suggestions[i].Value < suggestions[j].Value
+	})
+	return suggestions
+}
+
+// Keys are the label keys the model suggests values for. A nil model has
+// none.
+func (m *Model) Keys() []string {
+	if m == nil {
+		return nil
+	}
+	return m.keys
+}
+
+// Best returns the most likely value of key for line when it is at least
+// as confident as the configured minimum, or nil. A nil model suggests
+// nothing.
+func (m *Model) Best(line, key string) *Suggestion {
+	if m == nil {
+		return nil
+	}
+	suggestions := m.Suggest(line, key)
+	if len(suggestions) == 0 || suggestions[0].Confidence < m.min {
+		return nil
+	}
+	return suggestions[0]
+}
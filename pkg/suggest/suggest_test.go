@@ -0,0 +1,75 @@
+package suggest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/josephburnett/time-flies/pkg/types"
+)
+
+func TestTokens(t *testing.T) {
+	got := tokens("Fix bug https://Bug.example.com/123?x=1 in a parser")
+	want := []string{"host:bug.example.com", "fix", "bug", "in", "parser"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wanted %v. got %v", want, got)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	entry := func(line, cat string) *types.Entry {
+		labels := map[string]string{}
+		if cat != "" {
+			labels["cat"] = cat
+		}
+		return &types.Entry{Line: line, Labels: labels}
+	}
+	derived := entry("fix the blog", "customer")
+	derived.Derived = map[string]string{"cat": "rule 1"}
+	log := types.Log{{
+		Done: []*types.Entry{
+			entry("fix bug http://bug/1", "customer"),
+			entry("fix bug http://bug/2 in parser", "customer"),
+			entry("design review of api", "primary"),
+			entry("eng review of storage design", "primary"),
+			entry("write blog post", "community"),
+			entry("blog post about tf", "community"),
+			entry("unlabeled line", ""),
+			entry("pair on the api design", "customer,primary"),
+			derived,
+		},
+	}}
+	model := (&SuggestConfig{}).Train(log, []string{"cat"})
+	cases := []struct {
+		line string
+		want string
+	}{
+		{"triage http://bug/99", "customer"},
+		{"review the design doc", "primary"},
+		{"blog about it", "community"},
+	}
+	for _, c := range cases {
+		s := model.Best(c.line, "cat")
+		if s == nil {
+			t.Errorf("%v: wanted cat=%v. got nothing", c.line, c.want)
+			continue
+		}
+		if s.Value != c.want {
+			t.Errorf("%v: wanted cat=%v. got %v (%v)", c.line, c.want, s.Value, s.Confidence)
+		}
+	}
+	var sum float64
+	for _, s := range model.Suggest("anything at all", "cat") {
+		sum += s.Confidence
+	}
+	if sum < 0.999 || sum > 1.001 {
+		t.Errorf("wanted confidences to sum to 1. got %v", sum)
+	}
+	for _, s := range model.Suggest("pair on the api design", "cat") {
+		if s.Value == "customer,primary" {
+			t.Errorf("wanted the values of a list learned one by one. got %v", s.Value)
+		}
+	}
+	if got := model.Suggest("fix", "sub"); got != nil {
+		t.Errorf("wanted no suggestions for an untrained key. got %v", got)
+	}
+}
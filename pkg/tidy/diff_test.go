@@ -0,0 +1,38 @@
+package tidy
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	cases := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{{
+		name: "equal",
+		a:    "a\nb\n",
+		b:    "a\nb\n",
+		want: "",
+	}, {
+		name: "changed line",
+		a:    "a\nb\nc\n",
+		b:    "a\nB\nc\n",
+		want: "--- log\n+++ log (tidy)\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+	}, {
+		name: "separate hunks",
+		a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+		b:    "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+		want: "--- log\n+++ log (tidy)\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -7,4 +8,3 @@\n 7\n 8\n 9\n-10\n",
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := Diff("log", c.a, c.b)
+			if got != c.want {
+				t.Errorf("wanted %q. got %q", c.want, got)
+			}
+		})
+	}
+}
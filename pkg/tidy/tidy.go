@@ -4,17 +4,57 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/josephburnett/time-flies/pkg/file"
 	"github.com/josephburnett/time-flies/pkg/types"
 )
 
-type TidyConfig struct{}
+type TidyConfig struct {
+	// HeaderOrder is the order of header keys after Date. Other keys
+	// follow them.
+	HeaderOrder []string
+}
+
+// headerRank orders header keys: Date, then the configured keys, then
+// any others, which are sorted by name.
+func (c *TidyConfig) headerRank(key string) int {
+	if key == "Date" {
+		return 0
+	}
+	if c != nil {
+		for i, k := range c.HeaderOrder {
+			if k == key {
+				return i + 1
+			}
+		}
+		return len(c.HeaderOrder) + 1
+	}
+	return 1
+}
+
+// headerLess orders header keys by rank and then by name, in both the
+// log and the document tidy.
+func (c *TidyConfig) headerLess(a, b string) bool {
+	ra, rb := c.headerRank(a), c.headerRank(b)
+	if ra != rb {
+		return ra < rb
+	}
+	return a < b
+}
+
+// sortHeaderKeys sorts keys by rank and then by name.
+func (c *TidyConfig) sortHeaderKeys(keys []string) {
+	sort.Slice(keys, func(i, j int) bool {
+		return c.headerLess(keys[i], keys[j])
+	})
+}
 
 func (c *TidyConfig) SprintLog(log types.Log) (string, error) {
 	sort.Slice(log, func(i, j int) bool { return log[i].Date.After(log[j].Date) })
 	out := ""
 	for i, week := range log {
-		s, err := c.printWeek(week)
+		s, err := c.SprintWeek(week)
 		if err != nil {
 			return "", err
 		}
@@ -26,10 +66,113 @@ func (c *TidyConfig) SprintLog(log types.Log) (string, error) {
 	return out, nil
 }
 
-func (c *TidyConfig) printWeek(week *types.Week) (string, error) {
+// SprintDocument tidies a log file from its syntax tree. Dates, day
+// markers and entries are formatted consistently, labels are lined up and
+// weeks are sorted newest first. Comments, the order of entries, single
+// blank lines between them and headers other than Date are kept.
+func (c *TidyConfig) SprintDocument(doc *file.Document) (string, error) {
+	records := []*file.Record{}
+	for _, r := range doc.Records {
+		if strings.TrimSpace(r.String()) != "" {
+			records = append(records, r)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Date.After(records[j].Date) })
+	out := ""
+	for i, r := range records {
+		s, err := c.sprintRecord(r)
+		if err != nil {
+			return "", err
+		}
+		out += s
+		if i < len(records)-1 {
+			out += "%%\n"
+		}
+	}
+	return out, nil
+}
+
+func (c *TidyConfig) sprintRecord(r *file.Record) (string, error) {
+	out := ""
+	// Each header line is kept with its folded lines. Keys are sorted by
+	// rank and then by name, keeping the written order of repeated keys.
+	groups := [][]*file.Node{}
+	for _, n := range r.Header {
+		if n.Kind == file.FoldNode && len(groups) > 0 {
+			groups[len(groups)-1] = append(groups[len(groups)-1], n)
+			continue
+		}
+		groups = append(groups, []*file.Node{n})
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return c.headerLess(groups[i][0].Key, groups[j][0].Key)
+	})
+	for _, g := range groups {
+		for _, n := range g {
+			if n.Kind == file.HeaderNode && n.Key == "Date" && !r.Date.IsZero() {
+				out += fmt.Sprintf("Date: %v\n", r.Date.Format("Jan 02 2006"))
+				continue
+			}
+			out += strings.TrimRight(n.Text, " \t") + "\n"
+		}
+	}
+	out += "\n"
+	maxWidth := 0
+	for _, n := range r.Body {
+		if n.Kind != file.EntryNode || n.Entry == nil {
+			continue
+		}
+		width := len(n.Entry.Line)
+		if !n.Done {
+			width += 2
+		}
+		if width > maxWidth {
+			maxWidth = width
+		}
+	}
+	// Blank lines are kept between lines but not repeated, nor at the
+	// start or end of the body.
+	blank, started := false, false
+	for _, n := range r.Body {
+		if n.Kind == file.BlankNode {
+			blank = started
+			continue
+		}
+		if blank {
+			out += "\n"
+			blank = false
+		}
+		started = true
+		switch {
+		case n.Kind == file.DayNode:
+			out += fmt.Sprintf("--- %v\n", n.Weekday.String()[:3])
+		case n.Kind == file.EntryNode && n.Entry != nil:
+			status := "[ ]"
+			if n.Done {
+				status = "[x]"
+			}
+			s, err := c.printEntry(status, n.Entry, maxWidth)
+			if err != nil {
+				return "", err
+			}
+			out += s
+		default:
+			out += strings.TrimSpace(n.Text) + "\n"
+		}
+	}
+	out += "\n"
+	return out, nil
+}
+
+func (c *TidyConfig) SprintWeek(week *types.Week) (string, error) {
 	out := fmt.Sprintf("Date: %v\n", week.Date.Format("Jan 02 2006"))
-	for k, vs := range week.Header {
-		for _, v := range vs {
+	keys := []string{}
+	for k := range week.Header {
+		keys = append(keys, k)
+	}
+	c.sortHeaderKeys(keys)
+	for _, k := range keys {
+		for _, v := range week.Header[k] {
 			out += fmt.Sprintf("%v: %v\n", k, v)
 		}
 	}
@@ -45,28 +188,61 @@ func (c *TidyConfig) printWeek(week *types.Week) (string, error) {
 			maxWidth = len(entry.Line) + 2
 		}
 	}
+	// Entries without a day come first, followed by a section for each
+	// day. Within each, TODO entries follow completed entries.
+	days := []time.Time{}
+	done := map[time.Time][]*types.Entry{}
+	todo := map[time.Time][]*types.Entry{}
 	for _, entry := range week.Done {
-		out += fmt.Sprintf("[x] %v", entry.Line)
-		out += strings.Repeat(" ", maxWidth-len(entry.Line))
-		s, err := c.printLabels(entry.Labels)
-		if err != nil {
-			return "", err
+		if _, ok := done[entry.Date]; !ok && len(todo[entry.Date]) == 0 {
+			days = append(days, entry.Date)
 		}
-		out += fmt.Sprintf("  ##%v\n", s)
+		done[entry.Date] = append(done[entry.Date], entry)
 	}
 	for _, entry := range week.Todo {
-		out += fmt.Sprintf("[ ] %v", entry.Line)
-		out += strings.Repeat(" ", maxWidth-len(entry.Line))
-		s, err := c.printLabels(entry.Labels)
-		if err != nil {
-			return "", err
+		if _, ok := todo[entry.Date]; !ok && len(done[entry.Date]) == 0 {
+			days = append(days, entry.Date)
+		}
+		todo[entry.Date] = append(todo[entry.Date], entry)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	for i, day := range days {
+		if !day.IsZero() {
+			if i > 0 {
+				out += "\n"
+			}
+			out += fmt.Sprintf("--- %v\n", day.Format("Mon"))
+		}
+		for _, entry := range done[day] {
+			s, err := c.printEntry("[x]", entry, maxWidth)
+			if err != nil {
+				return "", err
+			}
+			out += s
+		}
+		for _, entry := range todo[day] {
+			s, err := c.printEntry("[ ]", entry, maxWidth)
+			if err != nil {
+				return "", err
+			}
+			out += s
 		}
-		out += fmt.Sprintf("  ##%v\n", s)
 	}
 	out += "\n"
 	return out, nil
 }
 
+func (c *TidyConfig) printEntry(status string, entry *types.Entry, maxWidth int) (string, error) {
+	out := fmt.Sprintf("%v %v", status, entry.Line)
+	out += strings.Repeat(" ", maxWidth-len(entry.Line))
+	s, err := c.printLabels(entry.Labels)
+	if err != nil {
+		return "", err
+	}
+	out += fmt.Sprintf("  ##%v\n", s)
+	return out, nil
+}
+
 func (c *TidyConfig) printLabels(labels map[string]string) (string, error) {
 	out := ""
 	keys := []string{}
@@ -76,7 +252,7 @@ func (c *TidyConfig) printLabels(labels map[string]string) (string, error) {
 	sort.Strings(keys)
 	for _, k := range keys {
 		v := labels[k]
-		out += fmt.Sprintf(" %v=%v", k, v)
+		out += " " + types.FormatLabel(k, v)
 	}
 	return out, nil
 }
@@ -0,0 +1,91 @@
+package tidy
+
+import (
+	"testing"
+
+	"github.com/josephburnett/time-flies/pkg/file"
+)
+
+func TestSprintDocument(t *testing.T) {
+	recordJar := `Date: Nov 23 2020
+Zeta: 1
+
+done it ## cat=a
+%%
+Date: November 30, 2020
+Mood: good
+Location:   home
+
+// planning notes
+[x] short ## cat=a
+# todo thing      ## cat=b
+
+
+
+Tuesday:
+a longer entry ##  sub=x cat=c
+`
+	want := `Date: Nov 30 2020
+Location:   home
+Mood: good
+
+// planning notes
+[x] short           ## cat=a
+[ ] todo thing      ## cat=b
+
+--- Tue
+[x] a longer entry  ## cat=c sub=x
+
+%%
+Date: Nov 23 2020
+Zeta: 1
+
+[x] done it  ## cat=a
+
+`
+	comments := true
+	fc := &file.FileConfig{Comments: &comments}
+	doc := fc.ParseDocument(recordJar)
+	if got := doc.String(); got != recordJar {
+		t.Errorf("wanted the document to print as parsed. got %q", got)
+	}
+	got, err := (&TidyConfig{}).SprintDocument(doc)
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if got != want {
+		t.Errorf("wanted %q. got %q", want, got)
+	}
+	again, err := (&TidyConfig{}).SprintDocument(fc.ParseDocument(got))
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if again != got {
+		t.Errorf("wanted tidy to be stable. got %q", again)
+	}
+}
+
+func TestHeaderOrder(t *testing.T) {
+	c := &TidyConfig{HeaderOrder: []string{"Mood", "Days"}}
+	recordJar := "Zeta: z\nDays: 4\nAlpha: a\nDate: Nov 23 2020\nMood: good\n  and rested\n\ndone ## cat=a\n"
+	want := "Date: Nov 23 2020\nMood: good\n  and rested\nDays: 4\nAlpha: a\nZeta: z\n\n[x] done  ## cat=a\n\n"
+	got, err := c.SprintDocument((*file.FileConfig)(nil).ParseDocument(recordJar))
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if got != want {
+		t.Errorf("wanted %q. got %q", want, got)
+	}
+	week, err := (*file.FileConfig)(nil).ParseWeek(recordJar)
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	got, err = c.SprintWeek(week)
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	want = "Date: Nov 23 2020\nMood: good and rested\nDays: 4\nAlpha: a\nZeta: z\n\n[x] done  ## cat=a\n\n"
+	if got != want {
+		t.Errorf("wanted %q. got %q", want, got)
+	}
+}
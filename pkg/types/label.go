@@ -0,0 +1,87 @@
+package types
+
+import (
+	"strings"
+	"unicode"
+)
+
+// A label value can be a list, e.g. "with=alice,bob". Entry.Labels holds
+// lists with their values joined by ",". Within each value "\" escapes a
+// "," and a "\" which would otherwise be read as an escape, so that most
+// values, like "C:\foo", are stored as they are written.
+
+// LabelValues splits a label value into its list of values.
+func LabelValues(value string) []string {
+	values := []string{}
+	current := strings.Builder{}
+	rs := []rune(value)
+	for i := 0; i < len(rs); i++ {
+		switch r := rs[i]; {
+		case r == '\\' && i+1 < len(rs) && (rs[i+1] == ',' || rs[i+1] == '\\'):
+			i++
+			current.WriteRune(rs[i])
+		case r == ',':
+			values = append(values, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(values, current.String())
+}
+
+// JoinLabelValues joins a list of values into one label value.
+func JoinLabelValues(values []string) string {
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		rs := []rune(v)
+		out := strings.Builder{}
+		for j, r := range rs {
+			switch {
+			case r == ',':
+				out.WriteString(`\,`)
+			case r == '\\' && (j+1 == len(rs) || rs[j+1] == ',' || rs[j+1] == '\\'):
+				out.WriteString(`\\`)
+			default:
+				out.WriteRune(r)
+			}
+		}
+		escaped[i] = out.String()
+	}
+	return strings.Join(escaped, ",")
+}
+
+// FormatLabel writes a label the way it is parsed from a log, quoting
+// values which contain whitespace or commas. Quotes are escaped, and so
+// are backslashes which would otherwise be read as an escape.
+func FormatLabel(key, value string) string {
+	values := LabelValues(value)
+	for i, v := range values {
+		rs := []rune(v)
+		out := strings.Builder{}
+		for j, r := range rs {
+			switch {
+			case r == '"':
+				out.WriteString(`\"`)
+			case r == '\\' && (j+1 == len(rs) || IsEscapable(rs[j+1])):
+				out.WriteString(`\\`)
+			default:
+				out.WriteRune(r)
+			}
+		}
+		values[i] = out.String()
+		if strings.IndexFunc(v, needsQuote) >= 0 {
+			values[i] = `"` + values[i] + `"`
+		}
+	}
+	return key + "=" + strings.Join(values, ",")
+}
+
+func needsQuote(r rune) bool {
+	return unicode.IsSpace(r) || r == ','
+}
+
+// IsEscapable reports whether a "\" before r is read as an escape.
+func IsEscapable(r rune) bool {
+	return r == '"' || r == ',' || r == '\\' || unicode.IsSpace(r)
+}
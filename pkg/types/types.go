@@ -1,6 +1,7 @@
 package types
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -11,9 +12,43 @@ type Week struct {
 	Header map[string][]string
 	Done   []*Entry
 	Todo   []*Entry
+	Pos    Position
 }
 
 type Entry struct {
 	Line   string
 	Labels map[string]string
+	// Date is the day of the entry when the week is divided into day
+	// sections. It is zero otherwise.
+	Date time.Time
+	Pos  Position
+	// Sources are the paths of the sources the entry was read from. There
+	// is more than one when several sources have the same entry.
+	Sources []string
+	// Derived maps the labels which were filled in by a rule to the name
+	// of the rule.
+	Derived map[string]string
+}
+
+// Position is where a week or entry was read from. Lines and columns
+// start at 1. Zero values are unknown.
+type Position struct {
+	File   string
+	Record int
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	s := p.File
+	if s == "" {
+		s = "log"
+	}
+	if p.Line > 0 {
+		s += fmt.Sprintf(":%v", p.Line)
+	}
+	if p.Column > 0 {
+		s += fmt.Sprintf(":%v", p.Column)
+	}
+	return s
 }
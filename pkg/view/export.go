@@ -0,0 +1,132 @@
+package view
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/josephburnett/time-flies/pkg/budget"
+)
+
+const (
+	exportDateFormat = "2006-01-02"
+)
+
+// Exported totals use hours for absolute time so they can be summed and
+// charted without parsing durations.
+type exportTotal struct {
+	Date      string
+	Period    budget.Period
+	Absolute  float64
+	Ratio     float64
+	SubTotals []*exportSubTotal
+}
+
+type exportSubTotal struct {
+	Label     string
+	Value     string
+	Path      string
+	Relative  float64
+	Absolute  float64
+	Count     int
+	Variance  float64
+	SubTotals []*exportSubTotal
+}
+
+func exportSubTotals(ss []*budget.SubTotal) []*exportSubTotal {
+	out := []*exportSubTotal{}
+	for _, s := range ss {
+		out = append(out, &exportSubTotal{
+			Label:     s.Label,
+			Value:     s.Value,
+			Path:      s.Path,
+			Relative:  s.Relative,
+			Absolute:  s.Absolute.Hours(),
+			Count:     s.Count,
+			Variance:  s.Variance,
+			SubTotals: exportSubTotals(s.SubTotals),
+		})
+	}
+	return out
+}
+
+func (c *ViewConfig) sprintJSON(totals budget.Totals) (string, error) {
+	out := []*exportTotal{}
+	for _, t := range totals {
+		out = append(out, &exportTotal{
+			Date:      t.Date.Format(exportDateFormat),
+			Period:    t.Period,
+			Absolute:  t.Absolute.Hours(),
+			Ratio:     t.Ratio,
+			SubTotals: exportSubTotals(t.SubTotals),
+		})
+	}
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// sprintCSV flattens totals into one row per SubTotal at any depth. The
+// path column identifies the SubTotal as a focus path, e.g.
+// "cat=primary/sub=thing-one" or "cat=primary/sub=?". The label_path
+// column is the whole value of a path-valued label, e.g.
+// "primary/thing-one" for the segment "thing-one".
+func (c *ViewConfig) sprintCSV(totals budget.Totals) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	err := w.Write([]string{
+		"date", "period", "total", "ratio",
+		"path", "label", "value", "label_path", "relative", "absolute", "count", "variance",
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, t := range totals {
+		prefix := []string{
+			t.Date.Format(exportDateFormat),
+			string(t.Period),
+			formatFloat(t.Absolute.Hours()),
+			formatFloat(t.Ratio),
+		}
+		if err := writeCSVRows(w, prefix, nil, t.SubTotals); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func writeCSVRows(w *csv.Writer, prefix, path []string, ss []*budget.SubTotal) error {
+	for _, s := range ss {
+		segment := &budget.FocusSegment{Label: s.Label, Value: s.Value}
+		p := append(append([]string{}, path...), segment.String())
+		row := append(append([]string{}, prefix...),
+			strings.Join(p, "/"),
+			s.Label,
+			s.Value,
+			s.Path,
+			formatFloat(s.Relative),
+			formatFloat(s.Absolute.Hours()),
+			strconv.Itoa(s.Count),
+			formatFloat(s.Variance),
+		)
+		if err := w.Write(row); err != nil {
+			return err
+		}
+		if err := writeCSVRows(w, prefix, p, s.SubTotals); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,123 @@
+package view
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josephburnett/time-flies/pkg/budget"
+)
+
+func exportTestTotals() budget.Totals {
+	return budget.Totals{{
+		Date:     time.Date(2020, time.November, 23, 0, 0, 0, 0, time.UTC),
+		Period:   budget.Weekly,
+		Absolute: 40 * time.Hour,
+		Ratio:    2.5,
+		SubTotals: []*budget.SubTotal{{
+			Label:    "cat",
+			Value:    "primary",
+			Path:     "primary",
+			Relative: 0.75,
+			Absolute: 30 * time.Hour,
+			Count:    3,
+			Variance: -0.05,
+			SubTotals: budget.SubTotals{{
+				Label:    "cat",
+				Value:    "thing, \"one\"",
+				Path:     "primary/thing, \"one\"",
+				Relative: 0.5,
+				Absolute: 20 * time.Hour,
+				Count:    2,
+			}, {
+				Label:    "cat",
+				Value:    "",
+				Path:     "primary",
+				Relative: 0.25,
+				Absolute: 10 * time.Hour,
+				Count:    1,
+			}},
+		}, {
+			Label:    "cat",
+			Value:    "",
+			Relative: 0.25,
+			Absolute: 10 * time.Hour,
+			Count:    1,
+		}},
+	}}
+}
+
+func TestSprintJSON(t *testing.T) {
+	want := `[
+  {
+    "Date": "2020-11-23",
+    "Period": "Weekly",
+    "Absolute": 40,
+    "Ratio": 2.5,
+    "SubTotals": [
+      {
+        "Label": "cat",
+        "Value": "primary",
+        "Path": "primary",
+        "Relative": 0.75,
+        "Absolute": 30,
+        "Count": 3,
+        "Variance": -0.05,
+        "SubTotals": [
+          {
+            "Label": "cat",
+            "Value": "thing, \"one\"",
+            "Path": "primary/thing, \"one\"",
+            "Relative": 0.5,
+            "Absolute": 20,
+            "Count": 2,
+            "Variance": 0,
+            "SubTotals": []
+          },
+          {
+            "Label": "cat",
+            "Value": "",
+            "Path": "primary",
+            "Relative": 0.25,
+            "Absolute": 10,
+            "Count": 1,
+            "Variance": 0,
+            "SubTotals": []
+          }
+        ]
+      },
+      {
+        "Label": "cat",
+        "Value": "",
+        "Path": "",
+        "Relative": 0.25,
+        "Absolute": 10,
+        "Count": 1,
+        "Variance": 0,
+        "SubTotals": []
+      }
+    ]
+  }
+]`
+	got, err := (*ViewConfig)(nil).sprintJSON(exportTestTotals())
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if got != want {
+		t.Errorf("wanted:\n%v\ngot:\n%v", want, got)
+	}
+}
+
+func TestSprintCSV(t *testing.T) {
+	want := `date,period,total,ratio,path,label,value,label_path,relative,absolute,count,variance
+2020-11-23,Weekly,40,2.5,cat=primary,cat,primary,primary,0.75,30,3,-0.05
+2020-11-23,Weekly,40,2.5,"cat=primary/cat=thing, ""one""",cat,"thing, ""one""","primary/thing, ""one""",0.5,20,2,0
+2020-11-23,Weekly,40,2.5,cat=primary/cat=?,cat,,primary,0.25,10,1,0
+2020-11-23,Weekly,40,2.5,cat=?,cat,,,0.25,10,1,0`
+	got, err := (*ViewConfig)(nil).sprintCSV(exportTestTotals())
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	if got != want {
+		t.Errorf("wanted:\n%v\ngot:\n%v", want, got)
+	}
+}
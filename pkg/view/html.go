@@ -0,0 +1,277 @@
+package view
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"github.com/josephburnett/time-flies/pkg/budget"
+)
+
+const (
+	chartWidth  = 600.0
+	chartHeight = 120.0
+	labelWidth  = 100.0
+	rowHeight   = 18.0
+)
+
+// htmlColors match the terminal colors of the Line format.
+var htmlColors = []string{
+	"#d9534f",
+	"#5cb85c",
+	"#d4a017",
+	"#428bca",
+	"#9b59b6",
+	"#17a2b8",
+}
+
+const htmlGrey = "#999999"
+
+type htmlReport struct {
+	Timeline   *htmlTimeline
+	Categories []*htmlCategory
+}
+
+type htmlCategory struct {
+	Label    string
+	Value    string
+	Color    string
+	Share    *htmlShare
+	Timeline *htmlTimeline
+}
+
+type htmlTimeline struct {
+	Width  float64
+	Height float64
+	Legend []*htmlLegend
+	Rows   []*htmlRow
+}
+
+type htmlLegend struct {
+	Name  string
+	Color string
+}
+
+type htmlRow struct {
+	Y     float64
+	TextY float64
+	Date  string
+	DaysX float64
+	Days  string
+	Rects []*htmlRect
+}
+
+type htmlRect struct {
+	X, Y, Width, Height float64
+	Color               string
+	Title               string
+	// Violation marks a share which is outside of its budget.
+	Violation bool
+}
+
+// htmlShare is a bar chart of a category's share of each period, with its
+// budget drawn as horizontal lines.
+type htmlShare struct {
+	Width  float64
+	Height float64
+	Bars   []*htmlRect
+	Lines  []*htmlLine
+}
+
+type htmlLine struct {
+	Y     float64
+	Width float64
+	Title string
+}
+
+// SprintHTML renders totals as a self-contained HTML page with inline SVG
+// charts: a timeline of all categories and a drill-down section for each
+// category with its budget.
+func (c *ViewConfig) SprintHTML(totals budget.Totals, bc *budget.BudgetConfig) (string, error) {
+	values := sortedValues(totals)
+	colors := valueColors(values)
+	report := &htmlReport{
+		Timeline: timeline(totals, values, colors),
+	}
+	for _, value := range values {
+		focused := totals.FocusPath([]*budget.FocusSegment{{Value: value}})
+		subValues := sortedValues(focused)
+		category := &htmlCategory{
+			Value:    displayValue(value),
+			Color:    colors[value],
+			Share:    share(totals, value, colors[value], bc),
+			Timeline: timeline(focused, subValues, valueColors(subValues)),
+		}
+		for _, t := range totals {
+			for _, s := range t.SubTotals {
+				if s.Value == value {
+					category.Label = s.Label
+				}
+			}
+		}
+		report.Categories = append(report.Categories, category)
+	}
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, report); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func valueColors(values []string) map[string]string {
+	colors := map[string]string{}
+	i := 0
+	for _, v := range values {
+		if v == "" {
+			colors[v] = htmlGrey
+			continue
+		}
+		colors[v] = htmlColors[i%len(htmlColors)]
+		i++
+	}
+	return colors
+}
+
+func displayValue(value string) string {
+	if value == "" {
+		return "?"
+	}
+	return value
+}
+
+func timeline(totals budget.Totals, values []string, colors map[string]string) *htmlTimeline {
+	tl := &htmlTimeline{
+		Width:  labelWidth + chartWidth + labelWidth,
+		Height: rowHeight * float64(len(totals)),
+	}
+	for _, v := range values {
+		tl.Legend = append(tl.Legend, &htmlLegend{
+			Name:  displayValue(v),
+			Color: colors[v],
+		})
+	}
+	for i, t := range totals {
+		y := float64(i) * rowHeight
+		row := &htmlRow{
+			Y:     y,
+			TextY: y + rowHeight*0.7,
+			Date:  t.Date.Format("Jan 02 2006"),
+			DaysX: labelWidth + chartWidth + 5,
+			Days:  fmt.Sprintf("%.1fd", t.Absolute.Hours()/8),
+		}
+		relative := map[string]*budget.SubTotal{}
+		for _, s := range t.SubTotals {
+			relative[s.Value] = s
+		}
+		x := labelWidth
+		for _, v := range values {
+			s, ok := relative[v]
+			if !ok {
+				continue
+			}
+			width := s.Relative * chartWidth
+			row.Rects = append(row.Rects, &htmlRect{
+				X:      x,
+				Y:      y + 1,
+				Width:  width,
+				Height: rowHeight - 2,
+				Color:  colors[v],
+				Title:  fmt.Sprintf("%v: %d%% (%.1fh)", displayValue(v), percent(s.Relative), s.Absolute.Hours()),
+			})
+			x += width
+		}
+		tl.Rows = append(tl.Rows, row)
+	}
+	return tl
+}
+
+func share(totals budget.Totals, value, color string, bc *budget.BudgetConfig) *htmlShare {
+	sh := &htmlShare{
+		Width:  chartWidth,
+		Height: chartHeight,
+	}
+	if len(totals) == 0 {
+		return sh
+	}
+	barWidth := chartWidth / float64(len(totals))
+	for i, t := range totals {
+		var relative, variance float64
+		for _, s := range t.SubTotals {
+			if s.Value == value {
+				relative, variance = s.Relative, s.Variance
+			}
+		}
+		title := fmt.Sprintf("%v: %d%%", t.Date.Format("Jan 02 2006"), percent(relative))
+		switch {
+		case variance > 0:
+			title += " (over budget)"
+		case variance < 0:
+			title += " (under budget)"
+		}
+		sh.Bars = append(sh.Bars, &htmlRect{
+			X:         float64(i)*barWidth + 1,
+			Y:         chartHeight * (1 - relative),
+			Width:     barWidth - 2,
+			Height:    chartHeight * relative,
+			Color:     color,
+			Title:     title,
+			Violation: variance != 0,
+		})
+	}
+	target := bc.GetTarget(value, totals[0].Period)
+	if target == nil {
+		return sh
+	}
+	if target.Min != nil {
+		sh.Lines = append(sh.Lines, &htmlLine{
+			Y:     chartHeight * (1 - *target.Min),
+			Width: chartWidth,
+			Title: fmt.Sprintf("min %d%%", percent(*target.Min)),
+		})
+	}
+	if target.Max != nil {
+		sh.Lines = append(sh.Lines, &htmlLine{
+			Y:     chartHeight * (1 - *target.Max),
+			Width: chartWidth,
+			Title: fmt.Sprintf("max %d%%", percent(*target.Max)),
+		})
+	}
+	return sh
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Time Flies</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #333; }
+svg text { font-size: 12px; fill: #333; }
+.legend span { display: inline-block; margin-right: 1em; }
+.swatch { display: inline-block; width: 1em; height: 1em; vertical-align: middle; margin-right: 0.3em; }
+.budget { stroke: #333; stroke-dasharray: 4 2; }
+.violation { stroke: #000; stroke-width: 2; }
+</style>
+</head>
+<body>
+<h1>Time Flies</h1>
+{{template "timeline" .Timeline}}
+{{range .Categories}}
+<h2><span class="swatch" style="background: {{.Color}}"></span>{{.Label}}={{.Value}}</h2>
+<svg width="{{.Share.Width}}" height="{{.Share.Height}}">
+<rect x="0" y="0" width="{{.Share.Width}}" height="{{.Share.Height}}" fill="#f4f4f4"/>
+{{range .Share.Bars}}<rect{{if .Violation}} class="violation"{{end}} x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}" fill="{{.Color}}"><title>{{.Title}}</title></rect>
+{{end}}{{range .Share.Lines}}<line class="budget" x1="0" y1="{{.Y}}" x2="{{.Width}}" y2="{{.Y}}"><title>{{.Title}}</title></line>
+<text x="2" y="{{.Y}}" dy="-2">{{.Title}}</text>
+{{end}}</svg>
+{{template "timeline" .Timeline}}
+{{end}}
+</body>
+</html>
+{{define "timeline"}}<div class="legend">{{range .Legend}}<span><span class="swatch" style="background: {{.Color}}"></span>{{.Name}}</span>{{end}}</div>
+<svg width="{{.Width}}" height="{{.Height}}">
+{{range .Rows}}<text x="0" y="{{.TextY}}">{{.Date}}</text>
+{{range .Rects}}<rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}" fill="{{.Color}}"><title>{{.Title}}</title></rect>
+{{end}}<text x="{{.DaysX}}" y="{{.TextY}}">{{.Days}}</text>
+{{end}}</svg>
+{{end}}`))
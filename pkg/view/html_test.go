@@ -0,0 +1,50 @@
+package view
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josephburnett/time-flies/pkg/budget"
+)
+
+func TestSprintHTML(t *testing.T) {
+	max := 0.5
+	bc := &budget.BudgetConfig{Targets: []*budget.Target{{Value: "<b>primary</b>", Max: &max}}}
+	totals := budget.Totals{{
+		Date:     time.Date(2020, time.November, 23, 0, 0, 0, 0, time.UTC),
+		Period:   budget.Weekly,
+		Absolute: 40 * time.Hour,
+		SubTotals: []*budget.SubTotal{{
+			Label:    "cat",
+			Value:    "<b>primary</b>",
+			Relative: 0.75,
+			Absolute: 30 * time.Hour,
+			Variance: 0.25,
+		}, {
+			Label:    "cat",
+			Value:    "",
+			Relative: 0.25,
+			Absolute: 10 * time.Hour,
+		}},
+	}}
+	got, err := (*ViewConfig)(nil).SprintHTML(totals, bc)
+	if err != nil {
+		t.Fatalf("wanted no error. got %v", err)
+	}
+	for _, want := range []string{
+		"&lt;b&gt;primary&lt;/b&gt;",
+		"Nov 23 2020",
+		`<title>max 50%</title>`,
+		`class="violation"`,
+		"75% (over budget)",
+		`cat=?`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("wanted %q in the report. got:\n%v", want, got)
+		}
+	}
+	if strings.Contains(got, "<b>primary</b>") {
+		t.Errorf("wanted label values escaped. got:\n%v", got)
+	}
+}
@@ -2,6 +2,7 @@ package view
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 
@@ -35,6 +36,8 @@ type Format string
 const (
 	LineFormat   Format = "Line"
 	NumberFormat Format = "Num"
+	JSONFormat   Format = "JSON"
+	CSVFormat    Format = "CSV"
 
 	defaultOutputFormat = LineFormat
 	defaultScreenWidth  = 100
@@ -86,30 +89,42 @@ func (c *ViewConfig) SprintTodo(log types.Log) (string, error) {
 }
 
 func (c *ViewConfig) SprintTotals(totals budget.Totals) (string, error) {
-	topLevelTotals := totals
+	// Each level of the focus path gets a perspective bar showing its share
+	// of the level above.
+	var path []*budget.FocusSegment
+	levels := []budget.Totals{}
 	if c.focusGroup() != "" {
-		focusedTotals, err := totals.Focus(c.focusGroup())
+		var err error
+		path, err = budget.ParseFocusPath(c.focusGroup())
 		if err != nil {
 			return "", err
 		}
-		topLevelTotals = totals
-		totals = focusedTotals
-	}
-	uniqueValues := map[string]bool{}
-	for _, t := range totals {
-		for _, s := range t.SubTotals {
-			uniqueValues[s.Value] = true
+		for i := range path {
+			levels = append(levels, totals.FocusPath(path[:i]))
 		}
+		levels[0] = totals
+		totals = totals.FocusPath(path)
 	}
-	sortedValues := []string{}
-	for v := range uniqueValues {
-		sortedValues = append(sortedValues, v)
+	switch c.outputFormat() {
+	case JSONFormat:
+		return c.sprintJSON(totals)
+	case CSVFormat:
+		return c.sprintCSV(totals)
 	}
-	sort.Strings(sortedValues)
+	values := sortedValues(totals)
 	out := ""
 	for i, total := range totals {
-		topTotal := topLevelTotals[i]
-		line, err := c.sprintTotal(total, topTotal, sortedValues)
+		perspective := []*budget.SubTotal{}
+		for l, level := range levels {
+			var s *budget.SubTotal
+			for _, ls := range level[i].SubTotals {
+				if path[l].Matches(ls) {
+					s = ls
+				}
+			}
+			perspective = append(perspective, s)
+		}
+		line, err := c.sprintTotal(total, perspective, values)
 		if err != nil {
 			return "", err
 		}
@@ -118,44 +133,89 @@ func (c *ViewConfig) SprintTotals(totals budget.Totals) (string, error) {
 	return out, nil
 }
 
-func (c *ViewConfig) sprintTotal(total, topTotal *budget.Total, values []string) (string, error) {
+func sortedValues(totals budget.Totals) []string {
+	unique := map[string]bool{}
+	for _, t := range totals {
+		for _, s := range t.SubTotals {
+			unique[s.Value] = true
+		}
+	}
+	values := []string{}
+	for v := range unique {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values
+}
+
+func (c *ViewConfig) SprintViolations(violations []*budget.Violation) (string, error) {
+	out := ""
+	for _, v := range violations {
+		bound, limit := "min", v.Target.Min
+		if v.Variance > 0 {
+			bound, limit = "max", v.Target.Max
+		}
+		out += fmt.Sprintf("%v %-9v %v=%-20v %3d%% %v %3d%% (%+d%%)\n",
+			v.Date.Format("Jan 02 2006"), v.Period, v.Label, v.Value,
+			percent(v.Relative), bound, percent(*limit), percent(v.Variance))
+	}
+	return out, nil
+}
+
+func percent(ratio float64) int {
+	return int(math.Round(ratio * 100))
+}
+
+// sprintTotal prints a bar of the total and, when focused, a perspective
+// bar for each level of the focus path. A nil perspective SubTotal has no
+// time in this period.
+func (c *ViewConfig) sprintTotal(total *budget.Total, perspective []*budget.SubTotal, values []string) (string, error) {
 	format := c.outputFormat()
 	if format != LineFormat && format != NumberFormat {
 		return "", fmt.Errorf("unsupported format: %v", c.OutputFormat)
 	}
 	screenWidth := float64(c.screenWidth())
-	if c.focusGroup() != "" {
+	perspectiveWidth := 0
+	if len(perspective) > 0 {
 		screenWidth = screenWidth / 2
+		perspectiveWidth = int(screenWidth) / len(perspective)
 	}
 	widthByValue := map[string]float64{}
 	relativeByValue := map[string]float64{}
+	varianceByValue := map[string]float64{}
 	for _, sub := range total.SubTotals {
 		widthByValue[sub.Value] = sub.Relative * screenWidth
 		relativeByValue[sub.Value] = sub.Relative
+		varianceByValue[sub.Value] = sub.Variance
 	}
 	out := fmt.Sprintf("%v %v   |", colorReset, total.Date.Format("Jan 02 2006"))
 	var cursor float64
 	i := 0
 	for _, value := range values {
 		width := widthByValue[value]
+		name := value
 		var color string
 		if value == "" {
 			color = colorGrey
-			value = "?"
+			name = "?"
 		} else {
 			color = colorIndex[i%len(colorIndex)]
 			i++
 		}
+		if varianceByValue[value] != 0 {
+			// Mark values which broke their budget.
+			name = "!" + name
+		}
 		chars := int(cursor+width) - int(cursor)
 		cursor += width
 		if format == LineFormat {
-			if len(value) > chars {
-				value = value[:chars]
+			if len(name) > chars {
+				name = name[:chars]
 			}
-			pad := chars - len(value)
+			pad := chars - len(name)
 			out += color
 			out += strings.Repeat("-", pad/2)
-			out += value
+			out += name
 			out += strings.Repeat("-", pad/2)
 			if pad%2 == 1 {
 				out += "-"
@@ -167,7 +227,7 @@ func (c *ViewConfig) sprintTotal(total, topTotal *budget.Total, values []string)
 			} else {
 				out += colorGrey
 			}
-			out += fmt.Sprintf(" %v (%3d%%) ", value, int(relativeByValue[value]*100))
+			out += fmt.Sprintf(" %v (%3d%%) ", name, int(relativeByValue[value]*100))
 		}
 		out += colorReset
 		out += "|"
@@ -180,17 +240,16 @@ func (c *ViewConfig) sprintTotal(total, topTotal *budget.Total, values []string)
 	if total.Ratio != 0.0 {
 		out += fmt.Sprintf(" fx=%.1f", total.Ratio)
 	}
-	if total != topTotal {
+	if len(perspective) > 0 {
 		out += " |"
-		var topTotalWidth int
-		for _, s := range topTotal.SubTotals {
-			if s.Value == c.focusGroup() {
-				topTotalWidth = int(s.Relative * float64(screenWidth))
-				out += strings.Repeat("-", topTotalWidth)
-			}
+	}
+	for _, s := range perspective {
+		var width int
+		if s != nil {
+			width = int(s.Relative * float64(perspectiveWidth))
 		}
-		out += "|"
-		out += strings.Repeat(" ", int(screenWidth)-topTotalWidth)
+		out += strings.Repeat("-", width)
+		out += strings.Repeat(" ", perspectiveWidth-width)
 		out += "|"
 	}
 	out += colorReset